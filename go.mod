@@ -5,6 +5,7 @@ go 1.24.0
 require (
 	github.com/docker/machine v0.16.2
 	github.com/hetznercloud/hcloud-go/v2 v2.32.0
+	go.yaml.in/yaml/v2 v2.4.3
 	golang.org/x/crypto v0.45.0
 )
 
@@ -24,7 +25,6 @@ require (
 	github.com/prometheus/common v0.67.4 // indirect
 	github.com/prometheus/procfs v0.19.2 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
-	go.yaml.in/yaml/v2 v2.4.3 // indirect
 	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/term v0.37.0 // indirect
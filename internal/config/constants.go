@@ -13,6 +13,14 @@ const (
 	DefaultWaitOnError           = 0
 	DefaultWaitOnPolling         = 1
 	DefaultWaitForRunningTimeout = 0
+	DefaultWaitForNetworkTimeout = 0
+	DefaultActionTimeout         = 0
+	DefaultOperationTimeout      = 0
+
+	DefaultAPIRetries      = 0
+	DefaultAPIRetryBackoff = 1
+
+	DefaultNetworkIPRange = "10.0.0.0/16"
 )
 
 const (
@@ -30,22 +38,57 @@ const (
 	FlagVolumes            = "hetzner-volumes"
 	FlagNetworks           = "hetzner-networks"
 	FlagUsePrivateNetwork  = "hetzner-use-private-network"
+	FlagPrivateNetwork     = "hetzner-private-network"
+	FlagAddressStrategy    = "hetzner-address-strategy"
+	FlagCreateNetworks     = "hetzner-create-networks"
+	FlagNetworkIPRange     = "hetzner-network-ip-range"
+	FlagNetworkSubnet      = "hetzner-network-subnet"
+	FlagNetworkZone        = "hetzner-network-zone"
+	FlagNetworkLabel       = "hetzner-network-label"
 	FlagDisablePublic4     = "hetzner-disable-public-ipv4"
 	FlagDisablePublic6     = "hetzner-disable-public-ipv6"
 	FlagPrimary4           = "hetzner-primary-ipv4"
 	FlagPrimary6           = "hetzner-primary-ipv6"
+	FlagNamedPrimaryIPs    = "hetzner-named-primary-ips"
+	FlagPrimaryAutoDelete  = "hetzner-primary-ip-auto-delete"
+	FlagKeepPrimaryIPs     = "hetzner-keep-primary-ips"
 	FlagDisablePublic      = "hetzner-disable-public"
+	FlagIPv6Host           = "hetzner-ipv6-host"
+	FlagRDNS               = "hetzner-rdns"
 	FlagFirewalls          = "hetzner-firewalls"
+	FlagFirewallRules      = "hetzner-firewall-rules-file"
+	FlagFirewallName       = "hetzner-firewall-name"
+	FlagDockerFirewall     = "hetzner-docker-firewall"
+	FlagDockerFirewallCIDR = "hetzner-docker-firewall-cidr"
+	FlagDockerFirewallICMP = "hetzner-docker-firewall-icmp"
+	FlagOperatorIP         = "hetzner-operator-ip"
+	FlagReconcileOnStart   = "hetzner-reconcile-on-start"
+	FlagFloatingIPs        = "hetzner-floating-ips"
+	FlagFloatingIPConfig   = "hetzner-floating-ip-configure"
+	FlagFloatingIPRelease  = "hetzner-floating-ip-release"
 	FlagAdditionalKeys     = "hetzner-additional-key"
 	FlagServerLabel        = "hetzner-server-label"
 	FlagKeyLabel           = "hetzner-key-label"
 	FlagPlacementGroup     = "hetzner-placement-group"
 	FlagAutoSpread         = "hetzner-auto-spread"
+	FlagKeepFailedServer   = "hetzner-keep-failed-server"
+	FlagExistingServer     = "hetzner-existing-server"
+	FlagCreateVolume       = "hetzner-create-volume"
+	FlagKeepVolumes        = "hetzner-keep-volumes"
+	FlagVolumeAutomount    = "hetzner-volume-automount"
+	FlagVolumeMountPoint   = "hetzner-volume-mount-point"
+	FlagVolumeFormat       = "hetzner-volume-format"
+	FlagRebuildExisting    = "hetzner-existing-server-rebuild"
 	FlagSSHUser            = "hetzner-ssh-user"
 	FlagSSHPort            = "hetzner-ssh-port"
 	FlagWaitOnError        = "hetzner-wait-on-error"
 	FlagWaitOnPolling      = "hetzner-wait-on-polling"
 	FlagWaitForRunning     = "hetzner-wait-for-running-timeout"
+	FlagWaitForNetwork     = "hetzner-wait-for-network-timeout"
+	FlagActionTimeout      = "hetzner-action-timeout"
+	FlagOperationTimeout   = "hetzner-operation-timeout"
+	FlagAPIRetries         = "hetzner-api-retries"
+	FlagAPIRetryBackoff    = "hetzner-api-retry-backoff"
 
 	LegacyFlagUserDataFromFile = "hetzner-user-data-from-file"
 	LegacyFlagDisablePublic4   = "hetzner-disable-public-4"
@@ -55,7 +98,15 @@ const (
 const (
 	LabelAutoSpreadPG = "auto-spread"
 	LabelAutoCreated  = "auto-created"
+	LabelAdopted      = "adopted"
+	LabelFWProfile    = "firewall-profile"
 	AutoSpreadPGName  = "__auto_spread"
+
+	// ownership labels, set on every resource the driver creates
+	LabelMachineName   = "machine-name"
+	LabelInstanceID    = "instance-id"
+	LabelDriverVersion = "driver-version"
+	LabelCreatedAt     = "created-at"
 )
 
 const EmptyImageArchitecture = hcloud.Architecture("")
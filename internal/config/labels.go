@@ -1,9 +1,37 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
 
 const labelPrefix = "docker-machine-driver-hetzner/"
 
+// Hetzner label values may only contain alphanumerics, '-', '_' and '.', and must start and end alphanumerically
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+const maxLabelValueLength = 63
+
 func LabelName(name string) string {
 	return fmt.Sprintf("%s%s", labelPrefix, name)
 }
+
+// LabelValue turns an arbitrary string into a valid label value
+func LabelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(value, "_")
+	if len(value) > maxLabelValueLength {
+		value = value[:maxLabelValueLength]
+	}
+	return strings.Trim(value, "._-")
+}
+
+// InstanceSelector returns a label selector matching all resources owned by the machine with the given instance ID
+func InstanceSelector(instanceID string) string {
+	return fmt.Sprintf("%s=%s", LabelName(LabelInstanceID), instanceID)
+}
+
+// IsDriverLabel reports whether the label name belongs to the driver's namespace
+func IsDriverLabel(name string) bool {
+	return strings.HasPrefix(name, labelPrefix)
+}
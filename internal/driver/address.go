@@ -0,0 +1,174 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	addressPublicIPv4  = "public-ipv4"
+	addressPublicIPv6  = "public-ipv6"
+	addressPreferIPv6  = "prefer-ipv6"
+	addressPrivate     = "private"
+	addressPrivateNet  = addressPrivate + ":"
+	addressStrategyAny = addressPublicIPv4 + ", " + addressPublicIPv6 + ", " + addressPreferIPv6 + ", " +
+		addressPrivate + " or " + addressPrivateNet + "<network>"
+)
+
+// parseAddressStrategy validates an ordered, comma-separated list of address sources, expanding `prefer-ipv6`
+func parseAddressStrategy(raw string) ([]string, error) {
+	var strategy []string
+	for _, entry := range splitList(raw) {
+		switch {
+		case entry == addressPublicIPv4, entry == addressPublicIPv6, entry == addressPrivate:
+			strategy = append(strategy, entry)
+		case entry == addressPreferIPv6:
+			strategy = append(strategy, addressPublicIPv6, addressPublicIPv4)
+		case strings.HasPrefix(entry, addressPrivateNet) && len(entry) > len(addressPrivateNet):
+			strategy = append(strategy, entry)
+		default:
+			return nil, fmt.Errorf("unknown address source %q, expected %v", entry, addressStrategyAny)
+		}
+	}
+	return strategy, nil
+}
+
+// getAddressStrategy returns the configured address strategy, or the one implied by the network flags
+func (d *Driver) getAddressStrategy() []string {
+	switch {
+	case len(d.AddressStrategy) != 0:
+		return d.AddressStrategy
+	case d.PrivateNetwork != "":
+		return []string{addressPrivateNet + d.PrivateNetwork}
+	case d.UsePrivateNetwork:
+		return []string{addressPrivate}
+	case d.DisablePublic4:
+		return []string{addressPublicIPv6}
+	default:
+		return []string{addressPublicIPv4}
+	}
+}
+
+// verifyAddressStrategy ensures private networks used for addressing are attached to the server
+func (d *Driver) verifyAddressStrategy(ctx context.Context) error {
+	for _, entry := range d.getAddressStrategy() {
+		if !strings.HasPrefix(entry, addressPrivate) {
+			continue
+		}
+		if len(d.Networks) == 0 {
+			return fmt.Errorf("%v requires --%v", entry, flagNetworks)
+		}
+
+		nameOrID, named := strings.CutPrefix(entry, addressPrivateNet)
+		if !named {
+			continue
+		}
+		chosen, err := d.getNetworkCached(ctx, nameOrID)
+		if err != nil {
+			return err
+		}
+		attached, err := d.attachesNetwork(ctx, chosen)
+		if err != nil {
+			return err
+		}
+		if !attached {
+			return fmt.Errorf("network %s is not in --%v", logging.Key(chosen.Name, chosen.ID), flagNetworks)
+		}
+	}
+	return nil
+}
+
+func (d *Driver) attachesNetwork(ctx context.Context, chosen *hcloud.Network) (bool, error) {
+	networks, err := d.resolveNetworks(ctx, d.Networks)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(networks, func(network *hcloud.Network) bool { return network.ID == chosen.ID }), nil
+}
+
+// publicIPv6Address returns the machine's address within the server's public IPv6 network
+func (d *Driver) publicIPv6Address(pv6 hcloud.ServerPublicNetIPv6) net.IP {
+	ip := pv6.IP
+	if ip.Mask(pv6.Network.Mask).Equal(pv6.Network.IP) { // no host given
+		ip = d.ipv6HostAddress(pv6.Network)
+	}
+	return ip
+}
+
+// selectAddress returns the server's address from the first source of the strategy it has. With wait, a private
+// network that is not attached yet ends the search with an empty address, so the caller can poll until it is.
+func (d *Driver) selectAddress(ctx context.Context, server *hcloud.Server, strategy []string, wait bool) (string, error) {
+	for _, entry := range strategy {
+		switch entry {
+		case addressPublicIPv4:
+			if ip := server.PublicNet.IPv4.IP; ip != nil && !ip.IsUnspecified() {
+				return ip.String(), nil
+			}
+		case addressPublicIPv6:
+			if pv6 := server.PublicNet.IPv6; pv6.Network != nil {
+				return d.publicIPv6Address(pv6).String(), nil
+			}
+		default:
+			var network *hcloud.Network
+			if nameOrID, named := strings.CutPrefix(entry, addressPrivateNet); named {
+				var err error
+				if network, err = d.getNetworkCached(ctx, nameOrID); err != nil {
+					return "", fmt.Errorf("could not get private network: %w", err)
+				}
+			}
+			if privateNet := selectPrivateNet(server, network); privateNet != nil {
+				return privateNet.IP.String(), nil
+			}
+			if wait {
+				return "", nil
+			}
+		}
+	}
+	return "", fmt.Errorf("server has no address matching %v", strings.Join(strategy, ","))
+}
+
+// GetIP resolves the machine's current address from the live server, following the same rules as on creation, so the
+// machine stays reachable after primary IPs are reassigned or networks change. The stored address is used if the
+// server cannot be queried in time.
+func (d *Driver) GetIP() (string, error) {
+	if d.ServerID == 0 {
+		return d.BaseDriver.GetIP()
+	}
+
+	ctx, cancel := d.operationContext()
+	defer cancel()
+	ctx, cancelRefresh := context.WithTimeoutCause(ctx, addressRefreshTimeout, errAddressRefreshTimeout)
+	defer cancelRefresh()
+
+	ip, err := d.resolveCurrentAddress(ctx)
+	if err != nil {
+		log.Debugf("Could not resolve current address, using stored one: %v", err)
+		return d.BaseDriver.GetIP()
+	}
+
+	if ip != d.IPAddress {
+		if d.IPAddress != "" {
+			log.Infof("Address of %v changed from %v to %v", d.GetMachineName(), d.IPAddress, ip)
+		}
+		d.IPAddress = ip
+	}
+	return ip, nil
+}
+
+func (d *Driver) resolveCurrentAddress(ctx context.Context) (string, error) {
+	srv, err := d.getClient().GetServerByID(ctx, d.ServerID)
+	if err != nil {
+		return "", fmt.Errorf("could not get server: %w", err)
+	}
+	if srv == nil {
+		return "", fmt.Errorf("server [%d] not found", d.ServerID)
+	}
+	return d.selectAddress(ctx, srv, d.getAddressStrategy(), false)
+}
@@ -0,0 +1,271 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	errServerOwnedElsewhere = errors.New("server belongs to another machine")
+	errSSHReadyTimeout      = errors.New("SSH did not become ready in time")
+)
+
+const (
+	// sshReadyTimeout bounds the wait for sshd of a rebuilt server, independent of --hetzner-operation-timeout
+	sshReadyTimeout = 5 * time.Minute
+	sshDialTimeout  = 10 * time.Second
+)
+
+// getExistingServer resolves the server to adopt, refusing servers created by the driver for another machine
+func (d *Driver) getExistingServer(ctx context.Context) (*hcloud.Server, error) {
+	srv, err := d.getClient().GetServer(ctx, d.ExistingServer)
+	if err != nil {
+		return nil, err
+	}
+	if !d.mayOwnServer(srv) {
+		return nil, fmt.Errorf("%w: %v", errServerOwnedElsewhere, logging.Server(srv.Name, srv.ID))
+	}
+
+	// the image used for rebuilding must match the server's architecture
+	d.cachedType = srv.ServerType
+	return instrumented(srv), nil
+}
+
+// adoptServer takes over an existing server instead of creating one; the server is never deleted by the driver
+func (d *Driver) adoptServer(ctx context.Context) error {
+	srv, err := d.getExistingServer(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get existing server: %w", err)
+	}
+
+	logging.Step("Adopting %s", logging.Server(srv.Name, srv.ID))
+	d.ServerID = srv.ID
+	d.IsExistingServer = true
+
+	if srv, err = d.labelAdoptedServer(ctx, srv); err != nil {
+		return fmt.Errorf("could not label existing server: %w", err)
+	}
+	d.Type = srv.ServerType.Name
+	if srv.Datacenter != nil && srv.Datacenter.Location != nil {
+		d.Location = srv.Datacenter.Location.Name
+	}
+
+	var rootPassword string
+	if d.rebuildExisting {
+		rootPassword, err = d.rebuildExistingServer(ctx, srv)
+		if err != nil {
+			return err
+		}
+	} else if srv.Status == hcloud.ServerStatusOff {
+		logging.Step("Powering on %s", logging.Server(srv.Name, srv.ID))
+		action, err := d.getClient().PowerOnServer(ctx, srv)
+		if err != nil {
+			return err
+		}
+		if err = d.waitForAction(ctx, action); err != nil {
+			return fmt.Errorf("could not wait for action: %w", err)
+		}
+	}
+
+	if err = d.waitForRunningServer(ctx); err != nil {
+		return err
+	}
+
+	if err = d.configureNetworkAccess(ctx, hcloud.ServerCreateResult{Server: srv}); err != nil {
+		return err
+	}
+
+	if rootPassword != "" {
+		if err = d.installMachineKey(ctx, rootPassword); err != nil {
+			return fmt.Errorf("could not install SSH key: %w", err)
+		}
+	}
+
+	logging.Step("Server %s ready at %s", logging.Server(srv.Name, srv.ID), d.IPAddress)
+	return nil
+}
+
+// labelAdoptedServer adds the machine name and instance ID labels, so that the server can be rediscovered like one
+// created by the driver, and marks it as adopted, which keeps gc from deleting it. The labels it lacked before are
+// recorded and removed again when the machine is removed or creation fails.
+func (d *Driver) labelAdoptedServer(ctx context.Context, srv *hcloud.Server) (*hcloud.Server, error) {
+	ownership := d.ownershipLabels()
+	wanted := map[string]string{
+		config.LabelName(config.LabelMachineName): ownership[config.LabelName(config.LabelMachineName)],
+		config.LabelName(config.LabelInstanceID):  ownership[config.LabelName(config.LabelInstanceID)],
+		config.LabelName(config.LabelAdopted):     "true",
+	}
+
+	labels := maps.Clone(srv.Labels)
+	if labels == nil {
+		labels = make(map[string]string, len(wanted))
+	}
+	var added []string
+	for name, value := range wanted {
+		if _, exists := labels[name]; !exists {
+			added = append(added, name)
+		}
+		labels[name] = value
+	}
+	if maps.Equal(labels, srv.Labels) {
+		return srv, nil
+	}
+
+	updated, err := d.getClient().UpdateServer(ctx, srv, hcloud.ServerUpdateOpts{Labels: labels})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(added)
+	d.AddedServerLabels = added
+
+	d.dangling = append(d.dangling, func(ctx context.Context) {
+		logging.Step("Removing labels from adopted %s", logging.Server(srv.Name, srv.ID))
+		if err := d.unlabelAdoptedServer(ctx); err != nil {
+			log.Errorf("Could not remove labels: %v", err)
+		}
+	})
+	return instrumented(updated), nil
+}
+
+// unlabelAdoptedServer removes the labels added on adoption, restoring the server's original labels
+func (d *Driver) unlabelAdoptedServer(ctx context.Context) error {
+	if len(d.AddedServerLabels) == 0 {
+		return nil
+	}
+
+	srv, err := d.getClient().GetServerByID(ctx, d.ServerID)
+	if err != nil || srv == nil {
+		return err
+	}
+
+	labels := maps.Clone(srv.Labels)
+	for _, name := range d.AddedServerLabels {
+		delete(labels, name)
+	}
+	if _, err = d.getClient().UpdateServer(ctx, srv, hcloud.ServerUpdateOpts{Labels: labels}); err != nil {
+		return err
+	}
+	d.AddedServerLabels = nil
+	return nil
+}
+
+// rebuildExistingServer rebuilds the server with the configured image and returns its new root password. Hetzner
+// re-injects the SSH keys the server was created with instead of issuing a password, if there were any; the machine
+// then logs in with the key given through --hetzner-existing-key-path.
+func (d *Driver) rebuildExistingServer(ctx context.Context, srv *hcloud.Server) (string, error) {
+	if err := d.verifyRebuildable(srv); err != nil {
+		return "", err
+	}
+
+	image, err := d.getImage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not get image: %w", err)
+	}
+
+	logging.Step("Rebuilding %s with image %s", logging.Server(srv.Name, srv.ID), image.Name)
+	result, err := d.getClient().RebuildServer(ctx, srv, hcloud.ServerRebuildOpts{Image: image})
+	if err != nil {
+		return "", err
+	}
+	if err = d.waitForAction(ctx, result.Action); err != nil {
+		return "", fmt.Errorf("could not wait for action: %w", err)
+	}
+
+	if result.RootPassword == "" && d.originalKey == "" {
+		return "", fmt.Errorf("server was created with SSH keys, which were restored by the rebuild; use --%v with one of them", flagExKeyPath)
+	}
+	return result.RootPassword, nil
+}
+
+// verifyRebuildable refuses to wipe a server that will not issue a root password on rebuild while no key to log in
+// with was given. Servers created by the driver, recognised by their ownership labels, always carry SSH keys; for
+// other servers the API does not tell, so a missing password can only be detected after the rebuild.
+func (d *Driver) verifyRebuildable(srv *hcloud.Server) error {
+	if d.originalKey != "" {
+		return nil
+	}
+	if _, labelled := srv.Labels[config.LabelName(config.LabelInstanceID)]; labelled {
+		return fmt.Errorf("%s was created with SSH keys, which a rebuild restores instead of issuing a root password; use --%v with one of them",
+			logging.Server(srv.Name, srv.ID), flagExKeyPath)
+	}
+	return nil
+}
+
+// installMachineKey authorizes the machine's public key for root, logging in with the given password
+func (d *Driver) installMachineKey(ctx context.Context, password string) error {
+	pubkey, err := os.ReadFile(d.GetSSHKeyPath() + ".pub")
+	if err != nil {
+		return fmt.Errorf("could not read ssh public key: %w", err)
+	}
+
+	port, err := d.GetSSHPort()
+	if err != nil {
+		return err
+	}
+
+	sshConfig := &ssh.ClientConfig{
+		User:            "root",
+		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // freshly rebuilt, same as docker-machine itself
+		Timeout:         sshDialTimeout,
+	}
+	addr := net.JoinHostPort(d.IPAddress, strconv.Itoa(port))
+
+	logging.Step("Installing SSH key on %s...", addr)
+
+	// sshd may take a moment to come up after the rebuild
+	ctx, cancel := context.WithTimeoutCause(ctx, sshReadyTimeout, errSSHReadyTimeout)
+	defer cancel()
+
+	var client *ssh.Client
+	for {
+		client, err = ssh.Dial("tcp", addr, sshConfig)
+		if err == nil {
+			break
+		}
+		if !isSSHNotReady(err) {
+			return err
+		}
+		logging.DebugStep("SSH not ready yet: %v", err)
+		if waitErr := d.waitPolling(ctx); waitErr != nil {
+			return fmt.Errorf("%w (%v)", waitErr, err)
+		}
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	cmd := fmt.Sprintf("mkdir -p ~/.ssh && chmod 700 ~/.ssh && echo '%s' >> ~/.ssh/authorized_keys && chmod 600 ~/.ssh/authorized_keys",
+		strings.TrimSpace(string(pubkey)))
+	if out, err := session.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+	return nil
+}
+
+// isSSHNotReady reports whether an SSH connection failed because sshd is not up yet, as opposed to e.g. the password
+// being rejected, which waiting does not remedy
+func isSSHNotReady(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)
+}
@@ -10,13 +10,61 @@ import (
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
+// destroyDangling removes resources left over by a failed creation; it uses a fresh context, as the one of the
+// failed operation may already have expired
 func (d *Driver) destroyDangling() {
-	for _, destructor := range d.dangling {
-		destructor()
+	if len(d.dangling) == 0 {
+		return
 	}
+
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	// resources are destroyed in reverse order of creation, so that e.g. a server is gone before its placement group
+	for i := len(d.dangling) - 1; i >= 0; i-- {
+		d.dangling[i](ctx)
+	}
+	d.dangling = nil
+}
+
+// rollbackFailedCreate destroys dangling resources after a failed creation, unless the server is to be kept for debugging
+func (d *Driver) rollbackFailedCreate() {
+	if len(d.dangling) == 0 {
+		return
+	}
+
+	if d.keepFailedServer && d.ServerID != 0 {
+		logging.WarnStep("Keeping failed server [ID: %d] and its resources for debugging; remove the machine to clean up", d.ServerID)
+		d.dangling = nil
+		return
+	}
+
+	d.destroyDangling()
 }
 
-func (d *Driver) removeEmptyServerPlacementGroup(srv *hcloud.Server) error {
+// makeServerDangling registers a freshly created server for removal should creation fail later on
+func (d *Driver) makeServerDangling(srv *hcloud.Server) {
+	d.journalRecord(journalKindServer, srv.ID, srv.Name)
+	d.dangling = append(d.dangling, func(ctx context.Context) {
+		logging.Step("Rolling back %s", logging.Server(srv.Name, srv.ID))
+
+		action, err := d.getClient().DeleteServer(ctx, srv)
+		if err != nil {
+			log.Errorf("Could not delete server: %v", err)
+			return
+		}
+		if err = d.waitForAction(ctx, action); err != nil {
+			log.Errorf("Could not wait for server deletion: %v", err)
+			return
+		}
+
+		d.ServerID = 0
+		d.cachedServer = nil
+		d.journalForget(journalKindServer, srv.ID)
+	})
+}
+
+func (d *Driver) removeEmptyServerPlacementGroup(ctx context.Context, srv *hcloud.Server) error {
 	pg := srv.PlacementGroup
 	if pg == nil {
 		return nil
@@ -28,7 +76,7 @@ func (d *Driver) removeEmptyServerPlacementGroup(srv *hcloud.Server) error {
 	}
 
 	if auto, exists := pg.Labels[config.LabelName(config.LabelAutoCreated)]; exists && auto == "true" {
-		err := d.getClient().DeletePlacementGroup(context.Background(), pg)
+		err := d.getClient().DeletePlacementGroup(ctx, pg)
 		if err != nil {
 			return err
 		}
@@ -39,12 +87,17 @@ func (d *Driver) removeEmptyServerPlacementGroup(srv *hcloud.Server) error {
 	}
 }
 
-func (d *Driver) destroyServer() error {
-	if d.ServerID == 0 {
+func (d *Driver) destroyServer(ctx context.Context) error {
+	if d.IsExistingServer {
+		logging.Step("Leaving adopted server [ID: %d] in place", d.ServerID)
+		// failure to restore the labels is not a hard error
+		if softErr := d.unlabelAdoptedServer(ctx); softErr != nil {
+			logging.WarnStep("Could not remove labels from adopted server: %v", softErr)
+		}
 		return nil
 	}
 
-	srv, err := d.getServerHandleNullable()
+	srv, err := d.getServerHandleNullable(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get server handle: %w", err)
 	}
@@ -54,18 +107,18 @@ func (d *Driver) destroyServer() error {
 	} else {
 		logging.Step("Destroying %s", logging.Server(srv.Name, srv.ID))
 
-		action, err := d.getClient().DeleteServer(context.Background(), srv)
+		action, err := d.getClient().DeleteServer(ctx, srv)
 		if err != nil {
 			return err
 		}
 
 		// failure to remove a placement group is not a hard error
-		if softErr := d.removeEmptyServerPlacementGroup(srv); softErr != nil {
+		if softErr := d.removeEmptyServerPlacementGroup(ctx, srv); softErr != nil {
 			log.Error(softErr)
 		}
 
 		// wait for the server to actually be deleted
-		if err = d.waitForAction(action); err != nil {
+		if err = d.waitForAction(ctx, action); err != nil {
 			return fmt.Errorf("could not wait for deletion: %w", err)
 		}
 	}
@@ -0,0 +1,293 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// dockerPort is the port of the TLS-protected Docker API, see [Driver.GetURL]
+const dockerPort = "2376"
+
+// dockerFirewallProfile tells Docker firewalls apart from other firewalls created by the driver
+const dockerFirewallProfile = "docker"
+
+// operatorIPEnv supplies the operator's egress address; besides being the environment variable of
+// --hetzner-operator-ip, it is consulted again on every start, as egress addresses tend to change
+const operatorIPEnv = "HETZNER_OPERATOR_IP"
+
+func (d *Driver) verifyDockerFirewallFlags() error {
+	if !d.DockerFirewall {
+		if len(d.DockerFirewallCIDRs) != 0 || d.OperatorIP != "" || d.DockerFirewallICMP {
+			return d.flagFailure("--%v, --%v and --%v require --%v",
+				flagDockerFirewallCIDR, flagOperatorIP, flagDockerFirewallICMP, flagDockerFirewall)
+		}
+		return nil
+	}
+
+	if len(d.DockerFirewallCIDRs) == 0 && d.OperatorIP == "" {
+		return d.flagFailure("--%v requires --%v or --%v", flagDockerFirewall, flagDockerFirewallCIDR, flagOperatorIP)
+	}
+	if _, err := parseFirewallCIDRs(d.DockerFirewallCIDRs); err != nil {
+		return d.flagFailure("--%v: %v", flagDockerFirewallCIDR, err)
+	}
+	if _, err := operatorIPNet(d.OperatorIP); err != nil {
+		return d.flagFailure("--%v: %v", flagOperatorIP, err)
+	}
+	if d.ExistingServer != "" {
+		return d.flagFailure("--%v and --%v are mutually exclusive", flagDockerFirewall, flagExistingServer)
+	}
+	return nil
+}
+
+// operatorIPNet turns the operator's egress address into a single-address network
+func operatorIPNet(raw string) (*net.IPNet, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	ip := net.ParseIP(raw)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", raw)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// dockerFirewallRules returns the rules of the Docker firewall: SSH and the Docker API, and ICMP if requested, from
+// the configured networks and the operator's address only
+func (d *Driver) dockerFirewallRules() ([]hcloud.FirewallRule, error) {
+	sources, err := parseFirewallCIDRs(d.DockerFirewallCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	operator, err := operatorIPNet(d.OperatorIP)
+	if err != nil {
+		return nil, err
+	}
+	if operator != nil {
+		sources = append(sources, *operator)
+	}
+
+	rules := []hcloud.FirewallRule{
+		{
+			Direction:   hcloud.FirewallRuleDirectionIn,
+			Protocol:    hcloud.FirewallRuleProtocolTCP,
+			Port:        hcloud.Ptr(strconv.Itoa(d.SSHPort)),
+			SourceIPs:   sources,
+			Description: hcloud.Ptr("SSH"),
+		},
+		{
+			Direction:   hcloud.FirewallRuleDirectionIn,
+			Protocol:    hcloud.FirewallRuleProtocolTCP,
+			Port:        hcloud.Ptr(dockerPort),
+			SourceIPs:   sources,
+			Description: hcloud.Ptr("Docker API"),
+		},
+	}
+	if d.DockerFirewallICMP {
+		rules = append(rules, hcloud.FirewallRule{
+			Direction:   hcloud.FirewallRuleDirectionIn,
+			Protocol:    hcloud.FirewallRuleProtocolICMP,
+			SourceIPs:   sources,
+			Description: hcloud.Ptr("ICMP"),
+		})
+	}
+	return rules, nil
+}
+
+func (d *Driver) dockerFirewallName() string {
+	return d.GetMachineName() + "-docker"
+}
+
+func dockerFirewallLabels() map[string]string {
+	return map[string]string{config.LabelName(config.LabelFWProfile): dockerFirewallProfile}
+}
+
+// getDockerFirewall creates the machine's Docker firewall, unless a Docker firewall of its name exists already, e.g.
+// left behind by an earlier machine of the same name; its rules are then reset
+func (d *Driver) getDockerFirewall(ctx context.Context) (*hcloud.Firewall, error) {
+	if d.cachedDockerFirewall != nil || !d.DockerFirewall {
+		return d.cachedDockerFirewall, nil
+	}
+
+	rules, err := d.dockerFirewallRules()
+	if err != nil {
+		return nil, err
+	}
+	firewall, err := d.findDockerFirewall(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if firewall == nil {
+		if firewall, err = d.makeFirewall(ctx, d.dockerFirewallName(), rules, dockerFirewallLabels()); err != nil {
+			return nil, err
+		}
+	} else if err = d.resetDockerFirewallRules(ctx, firewall, rules); err != nil {
+		return nil, err
+	}
+
+	d.DockerFirewallID = firewall.ID
+	d.cachedDockerFirewall = instrumented(firewall)
+	return d.cachedDockerFirewall, nil
+}
+
+// findDockerFirewall returns the existing firewall named like the machine's Docker firewall, if any. Firewalls not
+// created by the driver as a Docker firewall are refused instead of having their rules replaced.
+func (d *Driver) findDockerFirewall(ctx context.Context) (*hcloud.Firewall, error) {
+	firewall, err := d.getClient().GetFirewallByName(ctx, d.dockerFirewallName())
+	if err != nil || firewall == nil {
+		return nil, err
+	}
+	if firewall.Labels[config.LabelName(config.LabelAutoCreated)] != "true" ||
+		firewall.Labels[config.LabelName(config.LabelFWProfile)] != dockerFirewallProfile {
+		return nil, fmt.Errorf("firewall %s exists, but is not a Docker firewall created by the driver; delete or rename it",
+			logging.Key(firewall.Name, firewall.ID))
+	}
+	return firewall, nil
+}
+
+// resetDockerFirewallRules replaces the rules of an existing Docker firewall if they differ from the configured ones
+func (d *Driver) resetDockerFirewallRules(ctx context.Context, firewall *hcloud.Firewall, rules []hcloud.FirewallRule) error {
+	if firewallRulesEqual(firewall.Rules, rules) {
+		log.Debugf("Using existing Docker firewall %s", logging.Key(firewall.Name, firewall.ID))
+		return nil
+	}
+
+	logging.Step("Resetting rules of Docker firewall %s", logging.Key(firewall.Name, firewall.ID))
+	actions, err := d.getClient().SetFirewallRules(ctx, firewall, rules)
+	if err != nil {
+		return err
+	}
+	if err = d.waitForMultipleActions(ctx, "firewall.SetRules", actions); err != nil {
+		return fmt.Errorf("could not wait for firewall rules: %w", err)
+	}
+	return nil
+}
+
+// firewallRuleKey returns a canonical representation of the rule, ignoring its description
+func firewallRuleKey(rule hcloud.FirewallRule) string {
+	cidrs := func(nets []net.IPNet) string {
+		keys := make([]string, 0, len(nets))
+		for _, n := range nets {
+			keys = append(keys, n.String())
+		}
+		slices.Sort(keys)
+		return strings.Join(keys, ",")
+	}
+
+	port := ""
+	if rule.Port != nil {
+		port = *rule.Port
+	}
+	return fmt.Sprintf("%v %v %v from %v to %v", rule.Direction, rule.Protocol, port, cidrs(rule.SourceIPs), cidrs(rule.DestinationIPs))
+}
+
+func firewallRulesEqual(a, b []hcloud.FirewallRule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	keysA := make([]string, 0, len(a))
+	keysB := make([]string, 0, len(b))
+	for i := range a {
+		keysA = append(keysA, firewallRuleKey(a[i]))
+		keysB = append(keysB, firewallRuleKey(b[i]))
+	}
+	slices.Sort(keysA)
+	slices.Sort(keysB)
+	return slices.Equal(keysA, keysB)
+}
+
+func firewallAppliedToServer(firewall *hcloud.Firewall, srv *hcloud.Server) bool {
+	return slices.ContainsFunc(firewall.AppliedTo, func(res hcloud.FirewallResource) bool {
+		return res.Type == hcloud.FirewallResourceTypeServer && res.Server != nil && res.Server.ID == srv.ID
+	})
+}
+
+// syncDockerFirewall restores the Docker firewall of the machine, see planDockerFirewallChanges
+func (d *Driver) syncDockerFirewall(ctx context.Context, srv *hcloud.Server) error {
+	changes, err := d.planDockerFirewallChanges(ctx, srv)
+	if err != nil {
+		return err
+	}
+	return d.applyReconcile(ctx, changes)
+}
+
+// planDockerFirewallChanges compares the Docker firewall of the machine with its configuration: it is to be re-created
+// if it was deleted, its rules are to be reset if they were changed out of band, and it is to be applied to the server
+// again if it was removed from it. An operator address found in the environment replaces the stored one.
+func (d *Driver) planDockerFirewallChanges(ctx context.Context, srv *hcloud.Server) ([]reconcileChange, error) {
+	if !d.DockerFirewall {
+		return nil, nil
+	}
+
+	if raw, ok := os.LookupEnv(operatorIPEnv); ok && raw != d.OperatorIP {
+		if _, err := operatorIPNet(raw); err != nil {
+			return nil, fmt.Errorf("%v: %w", operatorIPEnv, err)
+		}
+		logging.Step("Using operator address %v from %v for Docker firewall", raw, operatorIPEnv)
+		d.OperatorIP = raw
+	}
+
+	rules, err := d.dockerFirewallRules()
+	if err != nil {
+		return nil, err
+	}
+
+	firewall, err := d.getClient().GetFirewallByID(ctx, d.DockerFirewallID)
+	if err != nil {
+		return nil, err
+	}
+	if firewall == nil {
+		return []reconcileChange{{
+			description: fmt.Sprintf("re-create deleted Docker firewall [ID: %d] and apply it", d.DockerFirewallID),
+			apply: func(ctx context.Context) error {
+				firewall, err := d.findDockerFirewall(ctx)
+				if err != nil {
+					return err
+				}
+				if firewall == nil {
+					if firewall, err = d.createFirewall(ctx, d.dockerFirewallName(), rules, dockerFirewallLabels()); err != nil {
+						return err
+					}
+				} else if err = d.resetDockerFirewallRules(ctx, firewall, rules); err != nil {
+					return err
+				}
+				d.DockerFirewallID = firewall.ID
+				d.cachedDockerFirewall = firewall
+				return d.applyFirewall(ctx, firewall, srv)
+			},
+		}}, nil
+	}
+
+	var changes []reconcileChange
+	if !firewallRulesEqual(firewall.Rules, rules) {
+		changes = append(changes, reconcileChange{
+			description: fmt.Sprintf("reset rules of Docker firewall %s", logging.Key(firewall.Name, firewall.ID)),
+			apply: func(ctx context.Context) error {
+				return d.resetDockerFirewallRules(ctx, firewall, rules)
+			},
+		})
+	}
+	if !firewallAppliedToServer(firewall, srv) {
+		changes = append(changes, reconcileChange{
+			description: fmt.Sprintf("apply Docker firewall %s", logging.Key(firewall.Name, firewall.ID)),
+			apply: func(ctx context.Context) error {
+				return d.applyFirewall(ctx, firewall, srv)
+			},
+		})
+	}
+	return changes, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"text/template"
 	"time"
 
 	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
@@ -30,32 +31,78 @@ type Driver struct {
 	cachedImage       *hcloud.Image
 	Type              string
 	cachedType        *hcloud.ServerType
+	cachedTypes       []*hcloud.ServerType
 	Location          string
 	cachedLocation    *hcloud.Location
+	cachedLocations   []*hcloud.Location
 	KeyID             int64
 	cachedKey         *hcloud.SSHKey
 	IsExistingKey     bool
 	originalKey       string
-	dangling          []func()
+	dangling          []func(context.Context)
 	ServerID          int64
+	InstanceID        string
 	cachedServer      *hcloud.Server
 	userData           string
 	userDataFile       string
 	additionalUserData string
 	Volumes           []string
+	volumeSpecs       []volumeSpec
+	CreatedVolumeIDs  []int64
+	KeepVolumes       bool
+	volumeAutomount   bool
+	volumeMountPoint  *template.Template
+	volumeFormat      string
 	Networks          []string
+	StaticNetworks     map[string]*staticNetworkAddress
+	autoCreateNetworks bool
+	networkIPRange     *net.IPNet
+	networkSubnet      *net.IPNet
+	networkZone        hcloud.NetworkZone
+	networkLabels      map[string]string
 	UsePrivateNetwork bool
+	PrivateNetwork    string
+	AddressStrategy   []string
+	cachedNetworks    map[string]*hcloud.Network
 	DisablePublic4    bool
 	DisablePublic6    bool
 	PrimaryIPv4       string
 	cachedPrimaryIPv4 *hcloud.PrimaryIP
 	PrimaryIPv6       string
 	cachedPrimaryIPv6 *hcloud.PrimaryIP
+	IPv6InterfaceID   string
+	RDNS              string
+	RDNSAddresses     []string
+	namedPrimaryIPs     bool
+	primaryIPAutoDelete bool
+	KeepPrimaryIPs      bool
+	NamedPrimaryIPIDs   []int64
 	Firewalls         []string
+	firewallRules       []hcloud.FirewallRule
+	firewallName        string
+	cachedRulesFirewall *hcloud.Firewall
+	RulesFirewallID     int64
+	DockerFirewall       bool
+	DockerFirewallCIDRs  []string
+	DockerFirewallICMP   bool
+	OperatorIP           string
+	DockerFirewallID     int64
+	cachedDockerFirewall *hcloud.Firewall
+	ReconcileOnStart     bool
+	floatingIPs          []string
+	cachedFloatingIPs    []*hcloud.FloatingIP
+	configureFloatingIPs bool
+	ReleaseFloatingIPs   bool
+	FloatingIPIDs        []int64
 	ServerLabels      map[string]string
 	keyLabels         map[string]string
 	placementGroup    string
 	cachedPGrp        *hcloud.PlacementGroup
+	keepFailedServer  bool
+	ExistingServer    string
+	IsExistingServer  bool
+	AddedServerLabels []string
+	rebuildExisting   bool
 
 	AdditionalKeys       []string
 	AdditionalKeyIDs     []int64
@@ -65,6 +112,13 @@ type Driver struct {
 	WaitOnPolling         int
 	WaitForRunningTimeout int
 
+	OperationTimeout      int
+	ActionTimeout         int
+	WaitForNetworkTimeout int
+
+	APIRetries      int
+	APIRetryBackoff int
+
 	// internal housekeeping
 	version string
 	usesDfr bool
@@ -86,19 +140,49 @@ const (
 	flagUserDataFile       = config.FlagUserDataFile
 	flagAdditionalUserData = config.FlagAdditionalUserData
 	flagVolumes            = config.FlagVolumes
+	flagCreateVolume       = config.FlagCreateVolume
+	flagKeepVolumes        = config.FlagKeepVolumes
+	flagVolumeAutomount    = config.FlagVolumeAutomount
+	flagVolumeMountPoint   = config.FlagVolumeMountPoint
+	flagVolumeFormat       = config.FlagVolumeFormat
 	flagNetworks           = config.FlagNetworks
 	flagUsePrivateNetwork  = config.FlagUsePrivateNetwork
+	flagPrivateNetwork     = config.FlagPrivateNetwork
+	flagAddressStrategy    = config.FlagAddressStrategy
+	flagCreateNetworks     = config.FlagCreateNetworks
+	flagNetworkIPRange     = config.FlagNetworkIPRange
+	flagNetworkSubnet      = config.FlagNetworkSubnet
+	flagNetworkZone        = config.FlagNetworkZone
+	flagNetworkLabel       = config.FlagNetworkLabel
 	flagDisablePublic4     = config.FlagDisablePublic4
 	flagDisablePublic6     = config.FlagDisablePublic6
 	flagPrimary4           = config.FlagPrimary4
 	flagPrimary6           = config.FlagPrimary6
+	flagNamedPrimaryIPs    = config.FlagNamedPrimaryIPs
+	flagPrimaryAutoDelete  = config.FlagPrimaryAutoDelete
+	flagKeepPrimaryIPs     = config.FlagKeepPrimaryIPs
 	flagDisablePublic      = config.FlagDisablePublic
+	flagIPv6Host           = config.FlagIPv6Host
+	flagRDNS               = config.FlagRDNS
 	flagFirewalls          = config.FlagFirewalls
+	flagFirewallRules      = config.FlagFirewallRules
+	flagFirewallName       = config.FlagFirewallName
+	flagDockerFirewall     = config.FlagDockerFirewall
+	flagDockerFirewallCIDR = config.FlagDockerFirewallCIDR
+	flagDockerFirewallICMP = config.FlagDockerFirewallICMP
+	flagOperatorIP         = config.FlagOperatorIP
+	flagReconcileOnStart   = config.FlagReconcileOnStart
+	flagFloatingIPs        = config.FlagFloatingIPs
+	flagFloatingIPConfig   = config.FlagFloatingIPConfig
+	flagFloatingIPRelease  = config.FlagFloatingIPRelease
 	flagAdditionalKeys     = config.FlagAdditionalKeys
 	flagServerLabel        = config.FlagServerLabel
 	flagKeyLabel           = config.FlagKeyLabel
 	flagPlacementGroup     = config.FlagPlacementGroup
 	flagAutoSpread         = config.FlagAutoSpread
+	flagKeepFailedServer   = config.FlagKeepFailedServer
+	flagExistingServer     = config.FlagExistingServer
+	flagRebuildExisting    = config.FlagRebuildExisting
 
 	flagSshUser = config.FlagSSHUser
 	flagSshPort = config.FlagSSHPort
@@ -113,6 +197,20 @@ const (
 	flagWaitForRunningTimeout    = config.FlagWaitForRunning
 	defaultWaitForRunningTimeout = config.DefaultWaitForRunningTimeout
 
+	flagOperationTimeout         = config.FlagOperationTimeout
+	defaultOperationTimeout      = config.DefaultOperationTimeout
+	flagActionTimeout            = config.FlagActionTimeout
+	defaultActionTimeout         = config.DefaultActionTimeout
+	flagWaitForNetworkTimeout    = config.FlagWaitForNetwork
+	defaultWaitForNetworkTimeout = config.DefaultWaitForNetworkTimeout
+
+	flagAPIRetries         = config.FlagAPIRetries
+	defaultAPIRetries      = config.DefaultAPIRetries
+	flagAPIRetryBackoff    = config.FlagAPIRetryBackoff
+	defaultAPIRetryBackoff = config.DefaultAPIRetryBackoff
+
+	defaultNetworkIPRange = config.DefaultNetworkIPRange
+
 	legacyFlagUserDataFromFile = config.LegacyFlagUserDataFromFile
 	legacyFlagDisablePublic4   = config.LegacyFlagDisablePublic4
 	legacyFlagDisablePublic6   = config.LegacyFlagDisablePublic6
@@ -164,13 +262,13 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 		mcnflag.StringFlag{
 			EnvVar: "HETZNER_TYPE",
 			Name:   flagType,
-			Usage:  "Server type to create",
+			Usage:  "Server type to create; a comma-separated list is tried in order",
 			Value:  defaultType,
 		},
 		mcnflag.StringFlag{
 			EnvVar: "HETZNER_LOCATION",
 			Name:   flagLocation,
-			Usage:  "Location to create machine at",
+			Usage:  "Location to create machine at; a comma-separated list of locations or network zones is tried in order",
 			Value:  "",
 		},
 		mcnflag.StringFlag{
@@ -214,10 +312,38 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 			Usage:  "Volume IDs or names which should be attached to the server",
 			Value:  []string{},
 		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "HETZNER_CREATE_VOLUMES",
+			Name:   flagCreateVolume,
+			Usage:  "Create a volume for the server, specified as size=<GB>[:name=<template>][:format=ext4|xfs][:automount=true]",
+			Value:  []string{},
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_KEEP_VOLUMES",
+			Name:   flagKeepVolumes,
+			Usage:  "Keep volumes created for the server when removing the machine",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_VOLUME_AUTOMOUNT",
+			Name:   flagVolumeAutomount,
+			Usage:  "Mount the volumes given by --hetzner-volumes when the server starts",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_VOLUME_MOUNT_POINT",
+			Name:   flagVolumeMountPoint,
+			Usage:  "Mount point template for automounted volumes, e.g. /mnt/{{.Name}}; mounts via cloud-init",
+			Value:  "",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_VOLUME_FORMAT",
+			Name:   flagVolumeFormat,
+			Usage:  "Filesystem (ext4 or xfs) to create on automounted volumes without one; mounts via cloud-init",
+			Value:  "",
+		},
 		mcnflag.StringSliceFlag{
 			EnvVar: "HETZNER_NETWORKS",
 			Name:   flagNetworks,
-			Usage:  "Network IDs or names which should be attached to the server private network interface",
+			Usage:  "Network IDs or names which should be attached to the server private network interface, optionally as network=ip[+alias...]",
 			Value:  []string{},
 		},
 		mcnflag.BoolFlag{
@@ -225,6 +351,47 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 			Name:   flagUsePrivateNetwork,
 			Usage:  "Use private network",
 		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_PRIVATE_NETWORK",
+			Name:   flagPrivateNetwork,
+			Usage:  "Network ID or name whose private address is used to connect to the machine (implies --hetzner-use-private-network)",
+			Value:  "",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_ADDRESS_STRATEGY",
+			Name:   flagAddressStrategy,
+			Usage:  "Ordered list of addresses to connect to: public-ipv4, public-ipv6, prefer-ipv6, private or private:<network>",
+			Value:  "",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_CREATE_NETWORKS",
+			Name:   flagCreateNetworks,
+			Usage:  "Create networks given by name in --hetzner-networks if they do not exist",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_NETWORK_IP_RANGE",
+			Name:   flagNetworkIPRange,
+			Usage:  "IP range of created networks",
+			Value:  defaultNetworkIPRange,
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_NETWORK_SUBNET",
+			Name:   flagNetworkSubnet,
+			Usage:  "Subnet of created networks servers are attached to (defaults to the whole IP range)",
+			Value:  "",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_NETWORK_ZONE",
+			Name:   flagNetworkZone,
+			Usage:  "Network zone of the subnet of created networks (defaults to the zone of the server location)",
+			Value:  "",
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "HETZNER_NETWORK_LABELS",
+			Name:   flagNetworkLabel,
+			Usage:  "Key value pairs of additional labels to assign to created networks",
+			Value:  []string{},
+		},
 		mcnflag.BoolFlag{
 			EnvVar: "HETZNER_DISABLE_PUBLIC_IPV4",
 			Name:   flagDisablePublic4,
@@ -262,12 +429,94 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 			Usage:  "Existing primary IPv6 address",
 			Value:  "",
 		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_NAMED_PRIMARY_IPS",
+			Name:   flagNamedPrimaryIPs,
+			Usage:  "Use primary IPs named after the machine (<machine>-ipv4/-ipv6), creating them if they do not exist",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_PRIMARY_IP_AUTO_DELETE",
+			Name:   flagPrimaryAutoDelete,
+			Usage:  "Let Hetzner delete named primary IPs along with the server",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_KEEP_PRIMARY_IPS",
+			Name:   flagKeepPrimaryIPs,
+			Usage:  "Keep named primary IPs for the next machine of the same name when removing the machine",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_IPV6_HOST",
+			Name:   flagIPv6Host,
+			Usage:  "Interface identifier of the machine's IPv6 address within its /64: random, machine-name or e.g. ::10 (default ::1)",
+			Value:  "",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_RDNS",
+			Name:   flagRDNS,
+			Usage:  "Reverse DNS template for the public IPs, e.g. {{.MachineName}}.nodes.example.com",
+			Value:  "",
+		},
 		mcnflag.StringSliceFlag{
 			EnvVar: "HETZNER_FIREWALLS",
 			Name:   flagFirewalls,
 			Usage:  "Firewall IDs or names which should be applied on the server",
 			Value:  []string{},
 		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_FIREWALL_RULES_FILE",
+			Name:   flagFirewallRules,
+			Usage:  "Path to a YAML or JSON rules file to create a firewall from, which is applied on the server",
+			Value:  "",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_FIREWALL_NAME",
+			Name:   flagFirewallName,
+			Usage:  "Name of the firewall created from the rules file; an existing firewall of that name is shared (default <machine>-firewall)",
+			Value:  "",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_DOCKER_FIREWALL",
+			Name:   flagDockerFirewall,
+			Usage:  "Create a firewall allowing SSH and the Docker API only from --hetzner-docker-firewall-cidr and --hetzner-operator-ip",
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "HETZNER_DOCKER_FIREWALL_CIDRS",
+			Name:   flagDockerFirewallCIDR,
+			Usage:  "Networks allowed to reach SSH and the Docker API through the Docker firewall",
+			Value:  []string{},
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_DOCKER_FIREWALL_ICMP",
+			Name:   flagDockerFirewallICMP,
+			Usage:  "Allow ICMP from the same sources through the Docker firewall",
+		},
+		mcnflag.StringFlag{
+			EnvVar: operatorIPEnv,
+			Name:   flagOperatorIP,
+			Usage:  "Egress address of the operator allowed through the Docker firewall, e.g. $(curl -s https://ipv4.icanhazip.com)",
+			Value:  "",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_RECONCILE_ON_START",
+			Name:   flagReconcileOnStart,
+			Usage:  "Bring firewalls, networks and labels of the server in line with the machine's configuration on every start",
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "HETZNER_FLOATING_IPS",
+			Name:   flagFloatingIPs,
+			Usage:  "Floating IP IDs, names or label selectors (label:<selector>) to assign to the server once it is running",
+			Value:  []string{},
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_FLOATING_IP_CONFIGURE",
+			Name:   flagFloatingIPConfig,
+			Usage:  "Bind floating IPs on the server through cloud-init",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_FLOATING_IP_RELEASE",
+			Name:   flagFloatingIPRelease,
+			Usage:  "Unassign floating IPs when stopping or removing the machine, and reassign them on start",
+		},
 		mcnflag.StringSliceFlag{
 			EnvVar: "HETZNER_ADDITIONAL_KEYS",
 			Name:   flagAdditionalKeys,
@@ -297,6 +546,22 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 			Name:   flagAutoSpread,
 			Usage:  "Auto-spread on a docker-machine-specific default placement group",
 		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_KEEP_FAILED_SERVER",
+			Name:   flagKeepFailedServer,
+			Usage:  "Keep the server and its resources for debugging if creation fails after the server was created",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "HETZNER_EXISTING_SERVER",
+			Name:   flagExistingServer,
+			Usage:  "Adopt an existing server (ID or name) instead of creating one; requires --hetzner-existing-key-path unless rebuilding",
+			Value:  "",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "HETZNER_EXISTING_SERVER_REBUILD",
+			Name:   flagRebuildExisting,
+			Usage:  "Rebuild the adopted server with the configured image to install the machine's SSH key; destroys all data on it",
+		},
 		mcnflag.StringFlag{
 			EnvVar: "HETZNER_SSH_USER",
 			Name:   flagSshUser,
@@ -327,6 +592,36 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 			Usage:  "Period for waiting for a machine to be running before failing",
 			Value:  defaultWaitForRunningTimeout,
 		},
+		mcnflag.IntFlag{
+			EnvVar: "HETZNER_WAIT_FOR_NETWORK_TIMEOUT",
+			Name:   flagWaitForNetworkTimeout,
+			Usage:  "Period for waiting for the private network to be attached before failing",
+			Value:  defaultWaitForNetworkTimeout,
+		},
+		mcnflag.IntFlag{
+			EnvVar: "HETZNER_ACTION_TIMEOUT",
+			Name:   flagActionTimeout,
+			Usage:  "Period for waiting for a single Hetzner action (e.g. server creation, power on) to complete before failing",
+			Value:  defaultActionTimeout,
+		},
+		mcnflag.IntFlag{
+			EnvVar: "HETZNER_OPERATION_TIMEOUT",
+			Name:   flagOperationTimeout,
+			Usage:  "Deadline for a whole driver operation (create, remove, start, stop, ...) before it is cancelled",
+			Value:  defaultOperationTimeout,
+		},
+		mcnflag.IntFlag{
+			EnvVar: "HETZNER_API_RETRIES",
+			Name:   flagAPIRetries,
+			Usage:  "Number of times to retry API calls failing due to rate limits, locks or transient errors (0 disables)",
+			Value:  defaultAPIRetries,
+		},
+		mcnflag.IntFlag{
+			EnvVar: "HETZNER_API_RETRY_BACKOFF",
+			Name:   flagAPIRetryBackoff,
+			Usage:  "Base period for the exponential backoff between API call retries",
+			Value:  defaultAPIRetryBackoff,
+		},
 	}
 }
 
@@ -375,14 +670,58 @@ func (d *Driver) setConfigFromFlagsImpl(opts drivers.DriverOptions) error {
 		return err
 	}
 	d.Volumes = opts.StringSlice(flagVolumes)
-	d.Networks = opts.StringSlice(flagNetworks)
+	for _, raw := range opts.StringSlice(flagCreateVolume) {
+		spec, err := parseVolumeSpec(raw)
+		if err != nil {
+			return d.flagFailure("--%v: %v", flagCreateVolume, err)
+		}
+		d.volumeSpecs = append(d.volumeSpecs, spec)
+	}
+	d.KeepVolumes = opts.Bool(flagKeepVolumes)
+	d.volumeAutomount = opts.Bool(flagVolumeAutomount)
+	d.volumeFormat = opts.String(flagVolumeFormat)
+	if mountPoint := opts.String(flagVolumeMountPoint); mountPoint != "" {
+		d.volumeMountPoint, err = parseTemplate("mount point", mountPoint)
+		if err == nil {
+			_, err = renderTemplate(d.volumeMountPoint, mountPointData{})
+		}
+		if err != nil {
+			return d.flagFailure("--%v: %v", flagVolumeMountPoint, err)
+		}
+	}
+	if err = d.setNetworksFromFlags(opts.StringSlice(flagNetworks)); err != nil {
+		return err
+	}
+	d.autoCreateNetworks = opts.Bool(flagCreateNetworks)
+	if d.autoCreateNetworks {
+		d.networkIPRange, d.networkSubnet, err = parseNetworkRanges(opts.String(flagNetworkIPRange), opts.String(flagNetworkSubnet))
+		if err != nil {
+			return d.flagFailure("--%v/--%v: %v", flagNetworkIPRange, flagNetworkSubnet, err)
+		}
+		d.networkZone = hcloud.NetworkZone(opts.String(flagNetworkZone))
+	}
 	disablePublic := opts.Bool(flagDisablePublic)
-	d.UsePrivateNetwork = opts.Bool(flagUsePrivateNetwork) || disablePublic
+	d.PrivateNetwork = opts.String(flagPrivateNetwork)
+	d.UsePrivateNetwork = opts.Bool(flagUsePrivateNetwork) || disablePublic || d.PrivateNetwork != ""
+	if d.AddressStrategy, err = parseAddressStrategy(opts.String(flagAddressStrategy)); err != nil {
+		return d.flagFailure("--%v: %v", flagAddressStrategy, err)
+	}
 	d.DisablePublic4 = d.deprecatedBooleanFlag(opts, flagDisablePublic4, legacyFlagDisablePublic4) || disablePublic
 	d.DisablePublic6 = d.deprecatedBooleanFlag(opts, flagDisablePublic6, legacyFlagDisablePublic6) || disablePublic
 	d.PrimaryIPv4 = opts.String(flagPrimary4)
 	d.PrimaryIPv6 = opts.String(flagPrimary6)
+	iid, err := resolveIPv6InterfaceID(opts.String(flagIPv6Host), d.GetMachineName())
+	if err != nil {
+		return d.flagFailure("--%v: %v", flagIPv6Host, err)
+	}
+	d.IPv6InterfaceID = iid.String()
+	d.namedPrimaryIPs = opts.Bool(flagNamedPrimaryIPs)
+	d.primaryIPAutoDelete = opts.Bool(flagPrimaryAutoDelete)
+	d.KeepPrimaryIPs = opts.Bool(flagKeepPrimaryIPs)
 	d.Firewalls = opts.StringSlice(flagFirewalls)
+	d.floatingIPs = opts.StringSlice(flagFloatingIPs)
+	d.configureFloatingIPs = opts.Bool(flagFloatingIPConfig)
+	d.ReleaseFloatingIPs = opts.Bool(flagFloatingIPRelease)
 	d.AdditionalKeys = opts.StringSlice(flagAdditionalKeys)
 
 	d.SSHUser = opts.String(flagSshUser)
@@ -391,6 +730,27 @@ func (d *Driver) setConfigFromFlagsImpl(opts drivers.DriverOptions) error {
 	d.WaitOnError = opts.Int(flagWaitOnError)
 	d.WaitOnPolling = opts.Int(flagWaitOnPolling)
 	d.WaitForRunningTimeout = opts.Int(flagWaitForRunningTimeout)
+	d.WaitForNetworkTimeout = opts.Int(flagWaitForNetworkTimeout)
+	d.ActionTimeout = opts.Int(flagActionTimeout)
+	d.OperationTimeout = opts.Int(flagOperationTimeout)
+
+	d.APIRetries = opts.Int(flagAPIRetries)
+	d.APIRetryBackoff = opts.Int(flagAPIRetryBackoff)
+
+	d.keepFailedServer = opts.Bool(flagKeepFailedServer)
+	d.ExistingServer = opts.String(flagExistingServer)
+	d.rebuildExisting = opts.Bool(flagRebuildExisting)
+	if err = d.setRDNSFromFlags(opts.String(flagRDNS)); err != nil {
+		return err
+	}
+	if err = d.setFirewallRulesFromFlags(opts.String(flagFirewallRules), opts.String(flagFirewallName)); err != nil {
+		return err
+	}
+	d.DockerFirewall = opts.Bool(flagDockerFirewall)
+	d.DockerFirewallCIDRs = opts.StringSlice(flagDockerFirewallCIDR)
+	d.DockerFirewallICMP = opts.Bool(flagDockerFirewallICMP)
+	d.OperatorIP = opts.String(flagOperatorIP)
+	d.ReconcileOnStart = opts.Bool(flagReconcileOnStart)
 
 	d.placementGroup = opts.String(flagPlacementGroup)
 	if opts.Bool(flagAutoSpread) {
@@ -419,6 +779,30 @@ func (d *Driver) setConfigFromFlagsImpl(opts drivers.DriverOptions) error {
 		return err
 	}
 
+	if err = d.verifyExistingServerFlags(); err != nil {
+		return err
+	}
+
+	if err = d.verifyVolumeMountFlags(); err != nil {
+		return err
+	}
+
+	if err = d.verifyIPv6HostFlags(); err != nil {
+		return err
+	}
+
+	if err = d.verifyNamedPrimaryIPFlags(); err != nil {
+		return err
+	}
+
+	if err = d.verifyFloatingIPFlags(); err != nil {
+		return err
+	}
+
+	if err = d.verifyDockerFirewallFlags(); err != nil {
+		return err
+	}
+
 	instrumented(d)
 
 	if d.usesDfr {
@@ -440,33 +824,84 @@ func (d *Driver) GetSSHPort() (int, error) {
 }
 
 func (d *Driver) PreCreateCheck() error {
-	if err := d.setupExistingKey(); err != nil {
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	if err := d.replayJournal(ctx); err != nil {
+		return fmt.Errorf("could not clean up after interrupted creation: %w", err)
+	}
+
+	if err := d.setupExistingKey(ctx); err != nil {
 		return err
 	}
 
-	if serverType, err := d.getType(); err != nil {
+	if d.ExistingServer != "" {
+		srv, err := d.getExistingServer(ctx)
+		if err != nil {
+			return fmt.Errorf("could not get existing server: %w", err)
+		}
+		if d.rebuildExisting {
+			if err = d.verifyRebuildable(srv); err != nil {
+				return err
+			}
+			if _, err := d.getImage(ctx); err != nil {
+				return fmt.Errorf("could not get image: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if serverTypes, err := d.getTypeCandidates(ctx); err != nil {
 		return fmt.Errorf("could not get type: %w", err)
-	} else if d.ImageArch != "" && serverType.Architecture != d.ImageArch {
-		log.Warnf("Supplied architecture %v differs from server architecture %v", d.ImageArch, serverType.Architecture)
+	} else if d.ImageArch != "" {
+		for _, serverType := range serverTypes {
+			if serverType.Architecture != d.ImageArch {
+				log.Warnf("Supplied architecture %v differs from server architecture %v of %v", d.ImageArch, serverType.Architecture, serverType.Name)
+			}
+		}
 	}
 
-	if _, err := d.getImage(); err != nil {
+	if _, err := d.getImage(ctx); err != nil {
 		return fmt.Errorf("could not get image: %w", err)
 	}
 
-	if _, err := d.getLocationNullable(); err != nil {
+	if _, err := d.getLocationCandidates(ctx); err != nil {
 		return fmt.Errorf("could not get location: %w", err)
 	}
 
-	if _, err := d.getPlacementGroup(); err != nil {
+	if _, err := d.getPlacementGroup(ctx); err != nil {
 		return fmt.Errorf("could not create placement group: %w", err)
 	}
 
-	if _, err := d.getPrimaryIPv4(); err != nil {
+	if err := d.ensureNetworks(ctx); err != nil {
+		return fmt.Errorf("could not create network: %w", err)
+	}
+
+	if err := d.verifyStaticNetworkAddresses(ctx); err != nil {
+		return fmt.Errorf("invalid network address: %w", err)
+	}
+
+	if err := d.verifyResourcePlacement(ctx); err != nil {
+		return fmt.Errorf("incompatible volumes or networks: %w", err)
+	}
+
+	if err := d.verifyVolumeNamesFree(ctx); err != nil {
+		return fmt.Errorf("could not create volume: %w", err)
+	}
+
+	if _, err := d.getRulesFirewall(ctx); err != nil {
+		return fmt.Errorf("could not create firewall: %w", err)
+	}
+
+	if _, err := d.getDockerFirewall(ctx); err != nil {
+		return fmt.Errorf("could not create Docker firewall: %w", err)
+	}
+
+	if _, err := d.getPrimaryIPv4(ctx); err != nil {
 		return fmt.Errorf("could not resolve primary IPv4: %w", err)
 	}
 
-	if _, err := d.getPrimaryIPv6(); err != nil {
+	if _, err := d.getPrimaryIPv6(ctx); err != nil {
 		return fmt.Errorf("could not resolve primary IPv6: %w", err)
 	}
 
@@ -474,55 +909,97 @@ func (d *Driver) PreCreateCheck() error {
 		return fmt.Errorf("no private network attached")
 	}
 
+	if err := d.verifyAddressStrategy(ctx); err != nil {
+		return fmt.Errorf("invalid address strategy: %w", err)
+	}
+
+	if _, err := d.getFloatingIPs(ctx); err != nil {
+		return fmt.Errorf("could not get floating IPs: %w", err)
+	}
+
+	if err := d.verifyCloudConfigUserData(); err != nil {
+		return fmt.Errorf("invalid user data: %w", err)
+	}
+
 	return nil
 }
 
 func (d *Driver) Create() error {
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
 	err := d.prepareLocalKey()
 	if err != nil {
 		return err
 	}
 
-	defer d.destroyDangling()
-	err = d.createRemoteKeys()
+	if d.ExistingServer != "" {
+		return d.adoptServer(ctx)
+	}
+
+	defer d.rollbackFailedCreate()
+	err = d.createRemoteKeys(ctx)
 	if err != nil {
 		return err
 	}
 
 	log.Info("Creating Hetzner server...")
 
-	srvopts, err := d.makeCreateServerOptions()
+	srv, err := d.createServerWithFallback(ctx)
 	if err != nil {
+		time.Sleep(time.Duration(d.WaitOnError) * time.Second)
 		return err
 	}
 
-	srv, err := d.getClient().CreateServer(context.Background(), instrumented(*srvopts))
-	if err != nil {
-		time.Sleep(time.Duration(d.WaitOnError) * time.Second)
-		return err
+	if srv.Action != nil {
+		logging.Step("Created %s, action: %s", logging.Server(srv.Server.Name, srv.Server.ID), logging.Action(srv.Action.Command, srv.Action.ID))
+	} else {
+		// recovered from a create call that failed ambiguously, see [hetzner.Client.CreateServer]
+		logging.Step("Created %s", logging.Server(srv.Server.Name, srv.Server.ID))
 	}
+	d.ServerID = srv.Server.ID
+	d.makeServerDangling(srv.Server)
 
-	logging.Step("Created %s, action: %s", logging.Server(srv.Server.Name, srv.Server.ID), logging.Action(srv.Action.Command, srv.Action.ID))
-	if err = d.waitForAction(srv.Action); err != nil {
+	if err = d.waitForAction(ctx, srv.Action); err != nil {
 		return fmt.Errorf("could not wait for action: %w", err)
 	}
 
-	d.ServerID = srv.Server.ID
+	if err = d.attachStaticNetworks(ctx, srv.Server); err != nil {
+		return err
+	}
+
+	if err = d.createMachineVolumes(ctx, srv.Server); err != nil {
+		return err
+	}
+
 	logging.Step("Waiting for %s to start...", logging.Server(srv.Server.Name, srv.Server.ID))
 
-	err = d.waitForInitialStartup(srv)
+	err = d.waitForInitialStartup(ctx, srv)
 	if err != nil {
 		return err
 	}
 
-	err = d.configureNetworkAccess(srv)
+	err = d.configureNetworkAccess(ctx, srv)
 	if err != nil {
 		return err
 	}
 
+	if err = d.assignFloatingIPs(ctx, srv.Server); err != nil {
+		return err
+	}
+
+	if err = d.setRDNS(ctx, srv.Server); err != nil {
+		return err
+	}
+
+	if err = d.claimNamedPrimaryIPs(ctx, srv.Server); err != nil {
+		return fmt.Errorf("could not claim primary IPs: %w", err)
+	}
+
 	logging.Step("Server %s ready at %s", logging.Server(srv.Server.Name, srv.Server.ID), d.IPAddress)
-	// Successful creation, so no keys dangle anymore
+	// Successful creation, so no resources dangle anymore
 	d.dangling = nil
+	d.clearJournal()
 
 	return nil
 }
@@ -541,11 +1018,18 @@ func (d *Driver) GetURL() (string, error) {
 		return "", fmt.Errorf("could not get IP: %w", err)
 	}
 
-	return fmt.Sprintf("tcp://%s", net.JoinHostPort(ip, "2376")), nil
+	return fmt.Sprintf("tcp://%s", net.JoinHostPort(ip, dockerPort)), nil
 }
 
 func (d *Driver) GetState() (state.State, error) {
-	srv, err := d.getClient().GetServerByID(context.Background(), d.ServerID)
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	return d.getState(ctx)
+}
+
+func (d *Driver) getState(ctx context.Context) (state.State, error) {
+	srv, err := d.lookupServer(ctx)
 	if err != nil {
 		return state.None, err
 	}
@@ -565,13 +1049,30 @@ func (d *Driver) GetState() (state.State, error) {
 }
 
 func (d *Driver) Remove() error {
-	if err := d.destroyServer(); err != nil {
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	d.releaseFloatingIPs(ctx)
+	d.resetRDNS(ctx)
+
+	if err := d.destroyServer(ctx); err != nil {
 		return err
 	}
 
+	d.removeNamedPrimaryIPs(ctx)
+	d.removeMachineVolumes(ctx)
+	d.removeEmptyAutoCreatedNetworks(ctx)
+	d.removeUnusedFirewall(ctx, d.RulesFirewallID)
+	d.removeUnusedFirewall(ctx, d.DockerFirewallID)
+
+	// failure to clean up after an interrupted creation is not a hard error
+	if softErr := d.replayJournal(ctx); softErr != nil {
+		logging.WarnStep("Could not clean up after interrupted creation: %v", softErr)
+	}
+
 	for i, id := range d.AdditionalKeyIDs {
 		logging.Step("Destroying additional SSH key #%d [ID: %d]", i, id)
-		key, softErr := d.getClient().GetSSHKeyByID(context.Background(), id)
+		key, softErr := d.getClient().GetSSHKeyByID(ctx, id)
 		if softErr != nil {
 			logging.WarnStep("Could not retrieve key: %v", softErr)
 			continue
@@ -581,14 +1082,14 @@ func (d *Driver) Remove() error {
 			continue
 		}
 
-		softErr = d.getClient().DeleteSSHKey(context.Background(), key)
+		softErr = d.getClient().DeleteSSHKey(ctx, key)
 		if softErr != nil {
 			logging.WarnStep("Could not remove key: %v", softErr)
 		}
 	}
 
 	if !d.IsExistingKey && d.KeyID != 0 {
-		key, err := d.getKeyNullable()
+		key, err := d.getKeyNullable(ctx)
 		if err != nil {
 			return fmt.Errorf("could not get ssh key: %w", err)
 		}
@@ -599,7 +1100,7 @@ func (d *Driver) Remove() error {
 
 		logging.Step("Destroying SSH key %s", logging.Key(key.Name, key.ID))
 
-		if err := d.getClient().DeleteSSHKey(context.Background(), key); err != nil {
+		if err := d.getClient().DeleteSSHKey(ctx, key); err != nil {
 			return err
 		}
 	}
@@ -608,7 +1109,10 @@ func (d *Driver) Remove() error {
 }
 
 func (d *Driver) Restart() error {
-	srv, err := d.getServerHandle()
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	srv, err := d.getServerHandle(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get server handle: %w", err)
 	}
@@ -616,60 +1120,83 @@ func (d *Driver) Restart() error {
 		return errors.New("server not found")
 	}
 
-	act, err := d.getClient().RebootServer(context.Background(), srv)
+	act, err := d.getClient().RebootServer(ctx, srv)
 	if err != nil {
 		return err
 	}
 
 	logging.Step("Rebooting %s, action: %s", logging.Server(srv.Name, srv.ID), logging.Action(act.Command, act.ID))
 
-	return d.waitForAction(act)
+	return d.waitForAction(ctx, act)
 }
 
 func (d *Driver) Start() error {
-	srv, err := d.getServerHandle()
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	srv, err := d.getServerHandle(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get server handle: %w", err)
 	}
 
-	act, err := d.getClient().PowerOnServer(context.Background(), srv)
+	if d.ReconcileOnStart {
+		if err = d.reconcile(ctx, srv); err != nil {
+			return fmt.Errorf("could not reconcile server: %w", err)
+		}
+	} else if err = d.syncDockerFirewall(ctx, srv); err != nil {
+		return fmt.Errorf("could not sync Docker firewall: %w", err)
+	}
+
+	act, err := d.getClient().PowerOnServer(ctx, srv)
 	if err != nil {
 		return err
 	}
 
 	logging.Step("Starting %s, action: %s", logging.Server(srv.Name, srv.ID), logging.Action(act.Command, act.ID))
 
-	return d.waitForAction(act)
+	if err = d.waitForAction(ctx, act); err != nil {
+		return err
+	}
+
+	return d.reassignFloatingIPs(ctx, srv)
 }
 
 func (d *Driver) Stop() error {
-	srv, err := d.getServerHandle()
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	srv, err := d.getServerHandle(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get server handle: %w", err)
 	}
 
-	act, err := d.getClient().ShutdownServer(context.Background(), srv)
+	d.releaseFloatingIPs(ctx)
+
+	act, err := d.getClient().ShutdownServer(ctx, srv)
 	if err != nil {
 		return err
 	}
 
 	logging.Step("Shutting down %s, action: %s", logging.Server(srv.Name, srv.ID), logging.Action(act.Command, act.ID))
 
-	return d.waitForAction(act)
+	return d.waitForAction(ctx, act)
 }
 
 func (d *Driver) Kill() error {
-	srv, err := d.getServerHandle()
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	srv, err := d.getServerHandle(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get server handle: %w", err)
 	}
 
-	act, err := d.getClient().PowerOffServer(context.Background(), srv)
+	act, err := d.getClient().PowerOffServer(ctx, srv)
 	if err != nil {
 		return err
 	}
 
 	logging.Step("Powering off %s, action: %s", logging.Server(srv.Name, srv.ID), logging.Action(act.Command, act.ID))
 
-	return d.waitForAction(act)
+	return d.waitForAction(ctx, act)
 }
@@ -1,11 +1,25 @@
 package driver
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/hetzner"
 	"github.com/docker/machine/commands/commandstest"
 	"github.com/docker/machine/libmachine/drivers"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
@@ -27,6 +41,32 @@ func makeFlags(args map[string]interface{}) drivers.DriverOptions {
 	return &commandstest.FakeFlagger{Data: combined}
 }
 
+// testAPI returns a client of a fake Hetzner API serving the given JSON responses, keyed by method, path and query
+// without pagination, e.g. `GET /servers?name=node`; other requests fail with not_found
+func testAPI(t *testing.T, responses map[string]string) *hetzner.Client {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		query.Del("page")
+		query.Del("per_page")
+		key := r.Method + " " + r.URL.Path
+		if len(query) != 0 {
+			unescaped, _ := url.QueryUnescape(query.Encode())
+			key += "?" + unescaped
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		body, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			body = fmt.Sprintf(`{"error": {"code": "not_found", "message": %q}}`, key)
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(api.Close)
+
+	return hetzner.NewClient(hetzner.ClientConfig{AdditionalOpts: []hcloud.ClientOption{hcloud.WithEndpoint(api.URL)}})
+}
+
 func TestUserData(t *testing.T) {
 	const fileContents = "User data from file"
 	const inlineContents = "User data"
@@ -412,3 +452,1348 @@ func TestAdditionalUserDataFlag(t *testing.T) {
 		t.Error("result should contain vim")
 	}
 }
+
+func TestExpandLocationCandidates(t *testing.T) {
+	all := []*hcloud.Location{
+		{ID: 1, Name: "fsn1", NetworkZone: hcloud.NetworkZoneEUCentral},
+		{ID: 2, Name: "nbg1", NetworkZone: hcloud.NetworkZoneEUCentral},
+		{ID: 3, Name: "hel1", NetworkZone: hcloud.NetworkZoneEUCentral},
+		{ID: 4, Name: "ash", NetworkZone: hcloud.NetworkZoneUSEast},
+	}
+
+	tests := []struct {
+		name        string
+		input       []string
+		expected    []string
+		expectError bool
+	}{
+		{"single location", []string{"nbg1"}, []string{"nbg1"}, false},
+		{"ordered list", []string{"hel1", "fsn1"}, []string{"hel1", "fsn1"}, false},
+		{"network zone", []string{"eu-central"}, []string{"fsn1", "nbg1", "hel1"}, false},
+		{"location before zone", []string{"hel1", "eu-central", "ash"}, []string{"hel1", "fsn1", "nbg1", "ash"}, false},
+		{"unknown", []string{"fsn1", "mars1"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := expandLocationCandidates(tt.input, all)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got %v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			names := make([]string, 0, len(result))
+			for _, location := range result {
+				names = append(names, location.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestVerifyLocationBoundResources(t *testing.T) {
+	fsn1 := &hcloud.Location{Name: "fsn1"}
+	nbg1 := &hcloud.Location{Name: "nbg1"}
+
+	opts := &hcloud.ServerCreateOpts{
+		Location: fsn1,
+		Volumes:  []*hcloud.Volume{{Name: "data", Location: fsn1}},
+		PublicNet: &hcloud.ServerCreatePublicNet{
+			IPv4: &hcloud.PrimaryIP{Name: "ingress", Datacenter: &hcloud.Datacenter{Location: fsn1}},
+		},
+	}
+	if err := verifyLocationBoundResources(opts); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	opts.Location = nil
+	if err := verifyLocationBoundResources(opts); err != nil {
+		t.Errorf("unexpected error without location: %v", err)
+	}
+
+	opts.Location = nbg1
+	if err := verifyLocationBoundResources(opts); !errors.Is(err, errLocationConflict) {
+		t.Errorf("expected location conflict, got %v", err)
+	}
+
+	opts.Volumes = nil
+	if err := verifyLocationBoundResources(opts); !errors.Is(err, errLocationConflict) {
+		t.Errorf("expected primary IP location conflict, got %v", err)
+	}
+}
+
+func TestIsTypeDeprecatedIn(t *testing.T) {
+	fsn1 := &hcloud.Location{Name: "fsn1"}
+	nbg1 := &hcloud.Location{Name: "nbg1"}
+	deprecated := hcloud.DeprecatableResource{Deprecation: &hcloud.DeprecationInfo{}}
+
+	stype := &hcloud.ServerType{
+		Name: "cx22",
+		Locations: []hcloud.ServerTypeLocation{
+			{Location: fsn1, DeprecatableResource: deprecated},
+			{Location: nbg1},
+		},
+	}
+
+	if !isTypeDeprecatedIn(stype, []*hcloud.Location{fsn1}) {
+		t.Error("expected type to be deprecated in fsn1")
+	}
+	if isTypeDeprecatedIn(stype, []*hcloud.Location{fsn1, nbg1}) {
+		t.Error("expected type to be usable in nbg1")
+	}
+	if isTypeDeprecatedIn(stype, []*hcloud.Location{nil}) {
+		t.Error("expected type to be usable in any location")
+	}
+
+	stype.Locations[1].DeprecatableResource = deprecated
+	if !isTypeDeprecatedIn(stype, []*hcloud.Location{nil}) {
+		t.Error("expected type to be deprecated everywhere")
+	}
+
+	if isTypeDeprecatedIn(&hcloud.ServerType{Name: "unknown"}, []*hcloud.Location{nil}) {
+		t.Error("type without location info must not be considered deprecated")
+	}
+}
+
+func TestVerifyImageArchitecture(t *testing.T) {
+	x86 := &hcloud.ServerType{Name: "cx33", Architecture: hcloud.ArchitectureX86}
+	arm := &hcloud.ServerType{Name: "cax31", Architecture: hcloud.ArchitectureARM}
+	image := &hcloud.Image{Name: "ubuntu-24.04", Architecture: hcloud.ArchitectureX86}
+
+	if err := verifyImageArchitecture(image, x86); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := verifyImageArchitecture(image, arm); !errors.Is(err, errArchitectureConflict) {
+		t.Errorf("expected architecture conflict, got %v", err)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), 0, errActionTimeout)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline for non-positive timeout")
+	}
+	cancel()
+
+	ctx, cancel = withTimeout(context.Background(), 60, errActionTimeout)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("expected deadline for positive timeout")
+	}
+}
+
+func TestWaitPollingReportsCause(t *testing.T) {
+	d := NewDriver("test")
+	d.WaitOnPolling = 60
+
+	parent, cancel := context.WithCancelCause(context.Background())
+	ctx, cancelChild := withTimeout(parent, 60, errWaitForRunningTimeout)
+	defer cancelChild()
+	cancel(errOperationTimeout)
+
+	if err := d.waitPolling(ctx); !errors.Is(err, errOperationTimeout) {
+		t.Errorf("expected operation timeout, got %v", err)
+	}
+	if err := contextError(ctx, context.Canceled); !errors.Is(err, errOperationTimeout) {
+		t.Errorf("expected operation timeout, got %v", err)
+	}
+	if err := contextError(context.Background(), errActionTimeout); err != errActionTimeout {
+		t.Errorf("expected error to be passed through, got %v", err)
+	}
+}
+
+func TestRollbackFailedCreate(t *testing.T) {
+	var order []string
+	register := func(d *Driver, name string) {
+		d.dangling = append(d.dangling, func(ctx context.Context) {
+			order = append(order, name)
+		})
+	}
+
+	// resources are destroyed in reverse order of creation
+	d := NewDriver("test")
+	register(d, "key")
+	register(d, "placement-group")
+	register(d, "server")
+	d.ServerID = 42
+	d.rollbackFailedCreate()
+
+	if strings.Join(order, ",") != "server,placement-group,key" {
+		t.Errorf("unexpected rollback order: %v", order)
+	}
+	if d.dangling != nil {
+		t.Error("expected dangling resources to be cleared")
+	}
+
+	// failed server is kept on request
+	order = nil
+	d = NewDriver("test")
+	d.keepFailedServer = true
+	register(d, "key")
+	register(d, "server")
+	d.ServerID = 42
+	d.rollbackFailedCreate()
+
+	if len(order) != 0 {
+		t.Errorf("expected resources to be kept, but destroyed %v", order)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	d := NewDriver("test")
+	d.StorePath = t.TempDir()
+	d.MachineName = "journal-test"
+
+	d.journalRecord(journalKindSSHKey, 1, "key")
+	d.journalRecord(journalKindPlacementGroup, 2, "group")
+	d.journalRecord(journalKindServer, 3, "server")
+
+	// a fresh driver instance, as after a process restart, sees all entries
+	restarted := NewDriver("test")
+	restarted.StorePath = d.StorePath
+	restarted.MachineName = d.MachineName
+
+	entries, err := restarted.readJournal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Kind != journalKindSSHKey || entries[2].ID != 3 {
+		t.Errorf("unexpected journal contents: %v", entries)
+	}
+
+	d.journalForget(journalKindPlacementGroup, 2)
+	entries, _ = restarted.readJournal()
+	if len(entries) != 2 {
+		t.Errorf("expected forgotten entry to be removed, got %v", entries)
+	}
+
+	d.clearJournal()
+	if _, err := os.Stat(d.journalPath()); !os.IsNotExist(err) {
+		t.Errorf("expected journal file to be removed, got %v", err)
+	}
+
+	// no store path, no journal
+	d = NewDriver("test")
+	d.journalRecord(journalKindServer, 3, "server")
+	if entries, err := d.readJournal(); err != nil || entries != nil {
+		t.Errorf("expected no journal without store path, got %v, %v", entries, err)
+	}
+}
+
+func TestDestroyUnusedNetwork(t *testing.T) {
+	d := NewDriver("test")
+	d.cachedClient = testAPI(t, map[string]string{
+		"GET /networks/1":    `{"network": {"id": 1, "name": "shared", "servers": [42]}}`,
+		"GET /networks/2":    `{"network": {"id": 2, "name": "empty", "servers": []}}`,
+		"DELETE /networks/2": ``,
+	})
+
+	// the fake API rejects deleting network 1, so success means it was kept
+	for _, id := range []int64{1, 2, 3} {
+		if err := d.destroyUnusedNetwork(context.Background(), id); err != nil {
+			t.Errorf("unexpected error for network %d: %v", id, err)
+		}
+	}
+}
+
+func TestRestorePrimaryIP(t *testing.T) {
+	d := NewDriver("test")
+	d.cachedClient = testAPI(t, map[string]string{
+		"GET /primary_ips/1":    `{"primary_ip": {"id": 1, "name": "primary_ip-1", "type": "ipv4", "ip": "1.2.3.4", "assignee_id": 42, "auto_delete": false}}`,
+		"PUT /primary_ips/1":    `{"primary_ip": {"id": 1, "name": "primary_ip-1", "type": "ipv4", "ip": "1.2.3.4", "assignee_id": 42, "auto_delete": true}}`,
+		"GET /primary_ips/2":    `{"primary_ip": {"id": 2, "name": "primary_ip-2", "type": "ipv4", "ip": "5.6.7.8", "auto_delete": false}}`,
+		"DELETE /primary_ips/2": ``,
+	})
+
+	// the fake API only accepts updating the assigned and deleting the unassigned primary IP
+	for _, id := range []int64{1, 2, 3} {
+		if err := d.restorePrimaryIP(context.Background(), id, false); err != nil {
+			t.Errorf("unexpected error for primary IP %d: %v", id, err)
+		}
+	}
+	if err := d.restorePrimaryIP(context.Background(), 2, true); err == nil {
+		t.Error("expected retained primary IP to be released instead of deleted")
+	}
+}
+
+func TestGetRulesFirewall(t *testing.T) {
+	rules := []hcloud.FirewallRule{{
+		Direction: hcloud.FirewallRuleDirectionIn,
+		Protocol:  hcloud.FirewallRuleProtocolTCP,
+		Port:      hcloud.Ptr("443"),
+		SourceIPs: []net.IPNet{{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)}},
+	}}
+	firewall := func(port, labels string) string {
+		return fmt.Sprintf(`{"firewalls": [{"id": 1, "name": "web", "labels": %s, "rules": [{"direction": "in", "protocol": "tcp", "port": %q, "source_ips": ["0.0.0.0/0"]}]}]}`, labels, port)
+	}
+	autoCreated := fmt.Sprintf(`{%q: "true"}`, config.LabelName(config.LabelAutoCreated))
+	setRules := `{"actions": [{"id": 5, "command": "set_firewall_rules", "status": "success", "progress": 100}]}`
+
+	tests := []struct {
+		name        string
+		responses   map[string]string
+		expectError bool
+	}{
+		{"same rules", map[string]string{"GET /firewalls?name=web": firewall("443", `{}`)}, false},
+		{"changed rules", map[string]string{"GET /firewalls?name=web": firewall("80", autoCreated), "POST /firewalls/1/actions/set_rules": setRules}, false},
+		{"changed rules of foreign firewall", map[string]string{"GET /firewalls?name=web": firewall("80", `{}`), "POST /firewalls/1/actions/set_rules": setRules}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			d.firewallName = "web"
+			d.firewallRules = rules
+			d.cachedClient = testAPI(t, tt.responses)
+
+			firewall, err := d.getRulesFirewall(context.Background())
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if firewall.ID != 1 || d.RulesFirewallID != 1 {
+				t.Errorf("expected existing firewall to be used, got %v", firewall.ID)
+			}
+		})
+	}
+}
+
+func TestOwnershipLabels(t *testing.T) {
+	d := NewDriver("v1.2.3+dirty")
+	d.MachineName = "my.machine"
+
+	labels := d.withOwnershipLabels(map[string]string{
+		"env":                                    "prod",
+		config.LabelName(config.LabelInstanceID): "spoofed",
+	})
+
+	if labels["env"] != "prod" {
+		t.Errorf("expected user label to be kept, got %v", labels)
+	}
+	if d.InstanceID == "" || labels[config.LabelName(config.LabelInstanceID)] != d.InstanceID {
+		t.Errorf("expected instance ID %q to take precedence, got %v", d.InstanceID, labels)
+	}
+	if labels[config.LabelName(config.LabelMachineName)] != "my.machine" {
+		t.Errorf("unexpected machine name label: %v", labels)
+	}
+	if labels[config.LabelName(config.LabelDriverVersion)] != "v1.2.3_dirty" {
+		t.Errorf("expected sanitized version label, got %v", labels)
+	}
+
+	// instance ID is stable for the machine
+	id := d.InstanceID
+	d.withOwnershipLabels(nil)
+	if d.InstanceID != id {
+		t.Errorf("instance ID changed from %q to %q", id, d.InstanceID)
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	tests := map[string]string{
+		"simple":                 "simple",
+		"with space":             "with_space",
+		"-leading-and-trailing.": "leading-and-trailing",
+		strings.Repeat("a", 70):  strings.Repeat("a", 63),
+	}
+
+	for value, expected := range tests {
+		if result := config.LabelValue(value); result != expected {
+			t.Errorf("LabelValue(%q) = %q, want %q", value, result, expected)
+		}
+	}
+}
+
+func TestMayOwnServer(t *testing.T) {
+	d := NewDriver("test")
+	d.InstanceID = "abc"
+
+	tests := []struct {
+		labels   map[string]string
+		expected bool
+	}{
+		{nil, true},
+		{map[string]string{config.LabelName(config.LabelInstanceID): "abc"}, true},
+		{map[string]string{config.LabelName(config.LabelInstanceID): "other"}, false},
+	}
+
+	for _, tt := range tests {
+		if result := d.mayOwnServer(&hcloud.Server{Labels: tt.labels}); result != tt.expected {
+			t.Errorf("mayOwnServer(%v) = %v, want %v", tt.labels, result, tt.expected)
+		}
+	}
+}
+
+func TestLookupServer(t *testing.T) {
+	const (
+		byName   = "GET /servers?name=node"
+		noServer = `{"servers": []}`
+	)
+	byLabel := "GET /servers?label_selector=" + config.InstanceSelector("abc")
+	instanceLabel := func(id string) string {
+		return fmt.Sprintf(`{%q: %q}`, config.LabelName(config.LabelInstanceID), id)
+	}
+	server := func(id int64, labels, image string) string {
+		return fmt.Sprintf(`{"id": %d, "name": "node", "labels": %s, "server_type": {"name": "cx22"}, "image": %s}`, id, labels, image)
+	}
+	unlabelled := server(2, `{}`, `{"id": 5, "name": "ubuntu-24.04"}`)
+	ours := server(2, instanceLabel("abc"), `{"id": 6, "name": "debian-12"}`)
+	theirs := server(2, instanceLabel("other"), `{"id": 5, "name": "ubuntu-24.04"}`)
+	otherImage := server(2, `{}`, `{"id": 6, "name": "debian-12"}`)
+
+	tests := []struct {
+		name      string
+		serverID  int64
+		responses map[string]string
+		expected  int64
+	}{
+		{"stored ID", 1, map[string]string{"GET /servers/1": `{"server": ` + server(1, `{}`, `null`) + `}`}, 1},
+		{"by label", 1, map[string]string{byLabel: `{"servers": [` + ours + `]}`}, 2},
+		{"by name", 1, map[string]string{byLabel: noServer, byName: `{"servers": [` + unlabelled + `]}`}, 2},
+		{"by name, other image", 1, map[string]string{byLabel: noServer, byName: `{"servers": [` + otherImage + `]}`}, 0},
+		{"by name, other machine", 1, map[string]string{byLabel: noServer, byName: `{"servers": [` + theirs + `]}`}, 0},
+		{"by name, without stored ID", 0, map[string]string{byLabel: noServer, byName: `{"servers": [` + unlabelled + `]}`}, 0},
+		{"nothing found", 1, map[string]string{byLabel: noServer, byName: noServer}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			d.MachineName = "node"
+			d.InstanceID = "abc"
+			d.ServerID = tt.serverID
+			d.Type = "cx22"
+			d.Image = "ubuntu-24.04"
+			d.cachedClient = testAPI(t, tt.responses)
+
+			srv, err := d.lookupServer(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			switch {
+			case tt.expected == 0 && srv != nil:
+				t.Errorf("expected no server, got %v", srv.ID)
+			case tt.expected != 0 && (srv == nil || srv.ID != tt.expected):
+				t.Errorf("expected server %v, got %v", tt.expected, srv)
+			case tt.expected != 0 && d.ServerID != tt.expected:
+				t.Errorf("expected stored server ID %v, got %v", tt.expected, d.ServerID)
+			}
+		})
+	}
+}
+
+func TestVerifyRebuildable(t *testing.T) {
+	labelled := &hcloud.Server{Labels: map[string]string{config.LabelName(config.LabelInstanceID): "abc"}}
+
+	d := NewDriver("test")
+	if err := d.verifyRebuildable(&hcloud.Server{}); err != nil {
+		t.Errorf("unexpected error for server without labels: %v", err)
+	}
+	if err := d.verifyRebuildable(labelled); err == nil {
+		t.Error("expected error for server created by the driver without key")
+	}
+
+	d.originalKey = "/tmp/id_ed25519"
+	if err := d.verifyRebuildable(labelled); err != nil {
+		t.Errorf("unexpected error with key: %v", err)
+	}
+}
+
+func TestIsSSHNotReady(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{fmt.Errorf("ssh: handshake failed: %w", io.EOF), true},
+		{fmt.Errorf("ssh: handshake failed: %w", &net.OpError{Op: "read", Err: syscall.ECONNRESET}), true},
+		{fmt.Errorf("ssh: handshake failed: %w", errors.New("ssh: unable to authenticate, attempted methods [none password], no supported methods remain")), false},
+	}
+
+	for _, tt := range tests {
+		if result := isSSHNotReady(tt.err); result != tt.expected {
+			t.Errorf("isSSHNotReady(%v) = %v, want %v", tt.err, result, tt.expected)
+		}
+	}
+}
+
+func TestPrivateNetworkImpliesUsePrivateNetwork(t *testing.T) {
+	d := NewDriver("test")
+	err := d.setConfigFromFlagsImpl(makeFlags(map[string]interface{}{
+		flagNetworks:       []string{"management", "cluster"},
+		flagPrivateNetwork: "cluster",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+
+	if !d.UsePrivateNetwork {
+		t.Error("expected private network to be enabled")
+	}
+}
+
+func TestSelectPrivateNet(t *testing.T) {
+	management := &hcloud.Network{ID: 1}
+	cluster := &hcloud.Network{ID: 2}
+	server := &hcloud.Server{PrivateNet: []hcloud.ServerPrivateNet{
+		{Network: management, IP: net.ParseIP("10.0.0.2")},
+		{Network: cluster, IP: net.ParseIP("10.1.0.2")},
+	}}
+
+	if privateNet := selectPrivateNet(server, nil); privateNet == nil || privateNet.Network.ID != management.ID {
+		t.Errorf("expected first attachment without chosen network, got %v", privateNet)
+	}
+	if privateNet := selectPrivateNet(server, cluster); privateNet == nil || !privateNet.IP.Equal(net.ParseIP("10.1.0.2")) {
+		t.Errorf("expected cluster attachment, got %v", privateNet)
+	}
+	if privateNet := selectPrivateNet(server, &hcloud.Network{ID: 3}); privateNet != nil {
+		t.Errorf("expected no attachment for unattached network, got %v", privateNet)
+	}
+}
+
+func TestParseAddressStrategy(t *testing.T) {
+	tests := []struct {
+		raw         string
+		expected    []string
+		expectError bool
+	}{
+		{"", nil, false},
+		{"public-ipv4", []string{"public-ipv4"}, false},
+		{"prefer-ipv6", []string{"public-ipv6", "public-ipv4"}, false},
+		{"private:cluster, public-ipv6", []string{"private:cluster", "public-ipv6"}, false},
+		{"private", []string{"private"}, false},
+		{"private:", nil, true},
+		{"ipv4", nil, true},
+	}
+
+	for _, tt := range tests {
+		strategy, err := parseAddressStrategy(tt.raw)
+		if tt.expectError {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", tt.raw, strategy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.raw, err)
+		} else if !slices.Equal(strategy, tt.expected) {
+			t.Errorf("%q: got %v, want %v", tt.raw, strategy, tt.expected)
+		}
+	}
+}
+
+func TestSelectAddress(t *testing.T) {
+	_, ipv6Net, _ := net.ParseCIDR("2a01:4f8:c17:1234::/64")
+	cluster := &hcloud.Network{ID: 2, Name: "cluster"}
+
+	d := NewDriver("test")
+	d.cachedNetworks = map[string]*hcloud.Network{"cluster": cluster}
+
+	server := &hcloud.Server{PublicNet: hcloud.ServerPublicNet{
+		IPv4: hcloud.ServerPublicNetIPv4{IP: net.ParseIP("1.2.3.4")},
+		IPv6: hcloud.ServerPublicNetIPv6{IP: ipv6Net.IP, Network: ipv6Net},
+	}}
+	ipv6Only := &hcloud.Server{PublicNet: hcloud.ServerPublicNet{
+		IPv6: hcloud.ServerPublicNetIPv6{IP: ipv6Net.IP, Network: ipv6Net},
+	}}
+	attached := &hcloud.Server{PrivateNet: []hcloud.ServerPrivateNet{
+		{Network: &hcloud.Network{ID: 1}, IP: net.ParseIP("10.0.0.2")},
+		{Network: cluster, IP: net.ParseIP("10.1.0.2")},
+	}}
+
+	tests := []struct {
+		name        string
+		server      *hcloud.Server
+		strategy    []string
+		wait        bool
+		expected    string
+		expectError bool
+	}{
+		{"public IPv4", server, []string{"public-ipv4"}, false, "1.2.3.4", false},
+		{"public IPv6", server, []string{"public-ipv6", "public-ipv4"}, false, "2a01:4f8:c17:1234::1", false},
+		{"IPv4 fallback to IPv6", ipv6Only, []string{"public-ipv4", "public-ipv6"}, false, "2a01:4f8:c17:1234::1", false},
+		{"no match", ipv6Only, []string{"public-ipv4"}, false, "", true},
+		{"any private network", attached, []string{"private"}, false, "10.0.0.2", false},
+		{"named private network", attached, []string{"private:cluster"}, false, "10.1.0.2", false},
+		{"private fallback", server, []string{"private:cluster", "public-ipv4"}, false, "1.2.3.4", false},
+		{"private pending", server, []string{"private:cluster", "public-ipv4"}, true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := d.selectAddress(context.Background(), tt.server, tt.strategy, tt.wait)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %v", ip)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ip != tt.expected {
+				t.Errorf("got %v, want %v", ip, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetIP(t *testing.T) {
+	server := func(publicNet, privateNet string) string {
+		return fmt.Sprintf(`{"server": {"id": 1, "name": "node", "public_net": %s, "private_net": %s}}`, publicNet, privateNet)
+	}
+	dualStack := `{"ipv4": {"ip": "5.6.7.8"}, "ipv6": {"ip": "2a01:4f8:c17:1234::/64"}}`
+	ipv6Only := `{"ipv4": null, "ipv6": {"ip": "2a01:4f8:c17:1234::/64"}}`
+	attached := `[{"network": 2, "ip": "10.1.0.2"}]`
+	cluster := `{"networks": [{"id": 2, "name": "cluster"}]}`
+
+	tests := []struct {
+		name      string
+		serverID  int64
+		strategy  []string
+		responses map[string]string
+		expected  string
+	}{
+		{"without server", 0, nil, nil, "1.2.3.4"},
+		{"changed public IPv4", 1, nil, map[string]string{"GET /servers/1": server(dualStack, `[]`)}, "5.6.7.8"},
+		{"IPv6 preferred", 1, []string{"public-ipv6", "public-ipv4"}, map[string]string{"GET /servers/1": server(dualStack, `[]`)}, "2a01:4f8:c17:1234::1"},
+		{"IPv4 fallback to IPv6", 1, []string{"public-ipv4", "public-ipv6"}, map[string]string{"GET /servers/1": server(ipv6Only, `[]`)}, "2a01:4f8:c17:1234::1"},
+		{"named private network", 1, []string{"private:cluster", "public-ipv4"},
+			map[string]string{"GET /servers/1": server(dualStack, attached), "GET /networks?name=cluster": cluster}, "10.1.0.2"},
+		{"detached private network", 1, []string{"private:cluster", "public-ipv4"},
+			map[string]string{"GET /servers/1": server(dualStack, `[]`), "GET /networks?name=cluster": cluster}, "5.6.7.8"},
+		{"no matching address", 1, []string{"public-ipv4"}, map[string]string{"GET /servers/1": server(ipv6Only, `[]`)}, "1.2.3.4"},
+		{"server gone", 1, nil, map[string]string{}, "1.2.3.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			d.MachineName = "node"
+			d.IPAddress = "1.2.3.4"
+			d.ServerID = tt.serverID
+			d.AddressStrategy = tt.strategy
+			d.cachedClient = testAPI(t, tt.responses)
+
+			ip, err := d.GetIP()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ip != tt.expected {
+				t.Errorf("got %v, want %v", ip, tt.expected)
+			}
+			if d.IPAddress != tt.expected {
+				t.Errorf("stored address %v, want %v", d.IPAddress, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultAddressStrategy(t *testing.T) {
+	d := NewDriver("test")
+	if strategy := d.getAddressStrategy(); !slices.Equal(strategy, []string{"public-ipv4"}) {
+		t.Errorf("unexpected default strategy %v", strategy)
+	}
+	d.DisablePublic4 = true
+	if strategy := d.getAddressStrategy(); !slices.Equal(strategy, []string{"public-ipv6"}) {
+		t.Errorf("unexpected strategy without IPv4 %v", strategy)
+	}
+	d.UsePrivateNetwork = true
+	d.PrivateNetwork = "cluster"
+	if strategy := d.getAddressStrategy(); !slices.Equal(strategy, []string{"private:cluster"}) {
+		t.Errorf("unexpected strategy with private network %v", strategy)
+	}
+	d.AddressStrategy = []string{"public-ipv6"}
+	if strategy := d.getAddressStrategy(); !slices.Equal(strategy, []string{"public-ipv6"}) {
+		t.Errorf("expected explicit strategy to take precedence, got %v", strategy)
+	}
+}
+
+func TestResolveIPv6InterfaceID(t *testing.T) {
+	tests := []struct {
+		raw         string
+		expected    string
+		expectError bool
+	}{
+		{"", "::1", false},
+		{"::10", "::10", false},
+		{"::dead:beef:0:1", "::dead:beef:0:1", false},
+		{"2a01::1", "", true},
+		{"::", "", true},
+		{"10.0.0.1", "", true},
+		{"foo", "", true},
+	}
+
+	for _, tt := range tests {
+		iid, err := resolveIPv6InterfaceID(tt.raw, "test")
+		if tt.expectError {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", tt.raw, iid)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.raw, err)
+		} else if iid.String() != tt.expected {
+			t.Errorf("%q: got %v, want %v", tt.raw, iid, tt.expected)
+		}
+	}
+
+	first, _ := resolveIPv6InterfaceID(ipv6HostMachineName, "node-1")
+	second, _ := resolveIPv6InterfaceID(ipv6HostMachineName, "node-1")
+	other, _ := resolveIPv6InterfaceID(ipv6HostMachineName, "node-2")
+	if !first.Equal(second) || first.Equal(other) {
+		t.Errorf("expected identifiers stable per machine name, got %v, %v and %v", first, second, other)
+	}
+
+	random, err := resolveIPv6InterfaceID(ipv6HostRandom, "test")
+	if err != nil || random.Mask(net.CIDRMask(64, 128)).String() != "::" {
+		t.Errorf("expected random identifier within lower 64 bits, got %v, %v", random, err)
+	}
+}
+
+func TestIPv6Host(t *testing.T) {
+	_, network, _ := net.ParseCIDR("2a01:4f8:c17:1234::/64")
+
+	d := NewDriver("test")
+	if ip := d.ipv6HostAddress(network); ip.String() != "2a01:4f8:c17:1234::1" {
+		t.Errorf("expected ::1 host by default, got %v", ip)
+	}
+	srvopts := hcloud.ServerCreateOpts{}
+	if err := d.setIPv6Host(&srvopts); err != nil || srvopts.UserData != "" {
+		t.Errorf("expected no user data for default host, got %q, %v", srvopts.UserData, err)
+	}
+
+	d.IPv6InterfaceID = "::dead:beef:0:1"
+	if ip := d.ipv6HostAddress(network); ip.String() != "2a01:4f8:c17:1234:dead:beef:0:1" {
+		t.Errorf("unexpected host address %v", ip)
+	}
+	srvopts = hcloud.ServerCreateOpts{UserData: "#cloud-config\npackages:\n- curl\n"}
+	if err := d.setIPv6Host(&srvopts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"bootcmd:", `"$prefix:dead:beef:0:1/64"`, "packages:"} {
+		if !strings.Contains(srvopts.UserData, expected) {
+			t.Errorf("expected user data to contain %q, got:\n%v", expected, srvopts.UserData)
+		}
+	}
+}
+
+func TestRDNS(t *testing.T) {
+	d := NewDriver("test")
+	d.MachineName = "relay-1"
+	err := d.setConfigFromFlagsImpl(makeFlags(map[string]interface{}{
+		flagRDNS:     "{{.MachineName}}.nodes.example.com",
+		flagIPv6Host: "::25",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if d.RDNS != "relay-1.nodes.example.com" {
+		t.Errorf("unexpected reverse DNS %v", d.RDNS)
+	}
+
+	_, ipv6Net, _ := net.ParseCIDR("2a01:4f8:c17:1234::/64")
+	srv := &hcloud.Server{PublicNet: hcloud.ServerPublicNet{
+		IPv4: hcloud.ServerPublicNetIPv4{IP: net.ParseIP("1.2.3.4")},
+		IPv6: hcloud.ServerPublicNetIPv6{IP: ipv6Net.IP, Network: ipv6Net},
+	}}
+	if ips := d.rdnsAddresses(srv); !slices.Equal(ips, []string{"1.2.3.4", "2a01:4f8:c17:1234::25"}) {
+		t.Errorf("unexpected reverse DNS addresses %v", ips)
+	}
+
+	err = d.setConfigFromFlagsImpl(makeFlags(map[string]interface{}{
+		flagRDNS: "{{.Unknown}}.example.com",
+	}))
+	if err == nil {
+		t.Error("expected error for unknown template field")
+	}
+}
+
+func TestSetFloatingIPConfig(t *testing.T) {
+	_, ipv6Net, _ := net.ParseCIDR("2a01:4f8:1:2::/64")
+	d := NewDriver("test")
+	d.floatingIPs = []string{"ingress-v4", "label:pool=ingress"}
+	d.cachedFloatingIPs = []*hcloud.FloatingIP{
+		{ID: 1, Type: hcloud.FloatingIPTypeIPv4, IP: net.ParseIP("1.2.3.4")},
+		{ID: 2, Type: hcloud.FloatingIPTypeIPv6, IP: ipv6Net.IP, Network: ipv6Net},
+	}
+
+	srvopts := hcloud.ServerCreateOpts{}
+	if err := d.setFloatingIPConfig(context.Background(), &srvopts); err != nil || srvopts.UserData != "" {
+		t.Errorf("expected no user data unless requested, got %q, %v", srvopts.UserData, err)
+	}
+
+	d.configureFloatingIPs = true
+	if err := d.setFloatingIPConfig(context.Background(), &srvopts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"ip -4 addr replace 1.2.3.4/32 dev",
+		"ip -6 addr replace 2a01:4f8:1:2::1/64 dev",
+	} {
+		if !strings.Contains(srvopts.UserData, expected) {
+			t.Errorf("expected user data to contain %q, got:\n%v", expected, srvopts.UserData)
+		}
+	}
+}
+
+func TestSetVolumeMounts(t *testing.T) {
+	volumes := []*hcloud.Volume{
+		{ID: 1, Name: "data", LinuxDevice: "/dev/disk/by-id/scsi-0HC_Volume_1"},
+		{ID: 2, Name: "logs"},
+	}
+
+	// Hetzner automount without custom mount points or formatting
+	d := NewDriver("test")
+	d.volumeAutomount = true
+	srvopts := hcloud.ServerCreateOpts{Volumes: volumes}
+	if err := d.setVolumeMounts(&srvopts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if srvopts.Automount == nil || !*srvopts.Automount || srvopts.UserData != "" {
+		t.Errorf("expected Hetzner automount, got %v and %q", srvopts.Automount, srvopts.UserData)
+	}
+
+	// cloud-init mounts merged into existing user data
+	d.volumeFormat = "xfs"
+	d.volumeMountPoint, _ = parseTemplate("mount point", "/srv/{{.Name}}")
+	srvopts = hcloud.ServerCreateOpts{Volumes: volumes, UserData: "#cloud-config\nmounts:\n- [swap, none, swap, sw, '0', '0']\n"}
+	if err := d.setVolumeMounts(&srvopts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if srvopts.Automount != nil {
+		t.Errorf("expected Hetzner automount to stay disabled")
+	}
+	for _, expected := range []string{
+		"/dev/disk/by-id/scsi-0HC_Volume_1\n  - /srv/data\n  - xfs",
+		"/dev/disk/by-id/scsi-0HC_Volume_2\n  - /srv/logs",
+		"filesystem: xfs",
+		"- swap",
+	} {
+		if !strings.Contains(srvopts.UserData, expected) {
+			t.Errorf("expected user data to contain %q, got:\n%s", expected, srvopts.UserData)
+		}
+	}
+
+	// nothing to do without volumes
+	srvopts = hcloud.ServerCreateOpts{}
+	if err := d.setVolumeMounts(&srvopts); err != nil || srvopts.UserData != "" {
+		t.Errorf("expected no changes without volumes, got %q, %v", srvopts.UserData, err)
+	}
+}
+
+func TestDockerFirewallRules(t *testing.T) {
+	d := NewDriver("test")
+	d.SSHPort = 2222
+	d.DockerFirewallCIDRs = []string{"192.0.2.0/24"}
+	d.OperatorIP = "2001:db8::1"
+
+	rules, err := d.dockerFirewallRules()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 || *rules[0].Port != "2222" || *rules[1].Port != dockerPort {
+		t.Fatalf("expected SSH and Docker API rules, got %v", rules)
+	}
+	if key := firewallRuleKey(rules[1]); key != "in tcp 2376 from 192.0.2.0/24,2001:db8::1/128 to " {
+		t.Errorf("unexpected Docker API rule %q", key)
+	}
+
+	d.DockerFirewallICMP = true
+	withICMP, err := d.dockerFirewallRules()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(withICMP) != 3 || withICMP[2].Protocol != hcloud.FirewallRuleProtocolICMP {
+		t.Errorf("expected additional ICMP rule, got %v", withICMP)
+	}
+
+	// order and descriptions do not matter when comparing with the live firewall
+	reordered := []hcloud.FirewallRule{withICMP[2], withICMP[0], withICMP[1]}
+	reordered[0].Description = nil
+	if !firewallRulesEqual(withICMP, reordered) {
+		t.Error("expected reordered rules to be equal")
+	}
+	if firewallRulesEqual(rules, withICMP) {
+		t.Error("expected rules without ICMP to differ")
+	}
+}
+
+func TestSyncDockerFirewallRecreates(t *testing.T) {
+	t.Setenv(operatorIPEnv, "203.0.113.7")
+
+	d := NewDriver("test")
+	d.MachineName = "node"
+	d.SSHPort = 22
+	d.DockerFirewall = true
+	d.OperatorIP = "203.0.113.7"
+	d.DockerFirewallID = 9
+	d.cachedClient = testAPI(t, map[string]string{
+		"GET /firewalls?name=node-docker":               `{"firewalls": []}`,
+		"POST /firewalls":                               `{"firewall": {"id": 10, "name": "node-docker", "applied_to": []}, "actions": []}`,
+		"POST /firewalls/10/actions/apply_to_resources": `{"actions": [{"id": 5, "command": "apply_firewall", "status": "success", "progress": 100}]}`,
+	})
+
+	// a rollback entry of some ongoing operation
+	d.dangling = append(d.dangling, func(context.Context) {})
+
+	if err := d.syncDockerFirewall(context.Background(), &hcloud.Server{ID: 1, Name: "node"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.DockerFirewallID != 10 {
+		t.Errorf("expected re-created firewall to be stored, got ID %d", d.DockerFirewallID)
+	}
+	if len(d.dangling) != 1 {
+		t.Errorf("expected rollback list to be left alone, got %d entries", len(d.dangling))
+	}
+}
+
+func TestGetDockerFirewall(t *testing.T) {
+	newDriver := func(labels string) *Driver {
+		d := NewDriver("test")
+		d.MachineName = "node"
+		d.SSHPort = 22
+		d.DockerFirewall = true
+		d.OperatorIP = "198.51.100.1"
+		d.cachedClient = testAPI(t, map[string]string{
+			"GET /firewalls?name=node-docker": `{"firewalls": [{"id": 5, "name": "node-docker", "labels": ` + labels + `, "rules": [
+				{"direction": "in", "protocol": "tcp", "port": "22", "source_ips": ["198.51.100.1/32"]},
+				{"direction": "in", "protocol": "tcp", "port": "2376", "source_ips": ["198.51.100.1/32"]}]}]}`,
+		})
+		return d
+	}
+
+	// a Docker firewall left behind by an earlier machine of the same name is reused; the fake API rejects creation
+	d := newDriver(`{"docker-machine-driver-hetzner/auto-created": "true", "docker-machine-driver-hetzner/firewall-profile": "docker"}`)
+	firewall, err := d.getDockerFirewall(context.Background())
+	if err != nil || firewall.ID != 5 || d.DockerFirewallID != 5 {
+		t.Errorf("expected existing firewall to be reused, got %v, %v", firewall, err)
+	}
+	if len(d.dangling) != 0 {
+		t.Errorf("expected reused firewall not to be rolled back, got %d entries", len(d.dangling))
+	}
+
+	// other firewalls of that name are neither reused nor overwritten
+	d = newDriver(`{"docker-machine-driver-hetzner/auto-created": "true"}`)
+	if _, err = d.getDockerFirewall(context.Background()); err == nil || !strings.Contains(err.Error(), "node-docker [ID: 5]") {
+		t.Errorf("expected error naming the conflicting firewall, got %v", err)
+	}
+}
+
+func TestResolveReferences(t *testing.T) {
+	byName := map[string]*hcloud.Network{"a": {ID: 1, Name: "a"}, "b": {ID: 2, Name: "b"}}
+	bySelector := map[string][]*hcloud.Network{"env=prod": {byName["b"], {ID: 3, Name: "c"}}}
+	resolve := func(refs ...string) ([]*hcloud.Network, error) {
+		return resolveReferences(refs, "network",
+			func(nameOrID string) (*hcloud.Network, error) {
+				if network, ok := byName[nameOrID]; ok {
+					return network, nil
+				}
+				return nil, fmt.Errorf("network '%s' not found", nameOrID)
+			},
+			func(selector string) ([]*hcloud.Network, error) { return bySelector[selector], nil },
+			func(network *hcloud.Network) int64 { return network.ID })
+	}
+
+	networks, err := resolve("a", "label:env=prod", "b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, network := range networks {
+		names = append(names, network.Name)
+	}
+	if !slices.Equal(names, []string{"a", "b", "c"}) {
+		t.Errorf("expected a, b, c in order without duplicates, got %v", names)
+	}
+
+	if _, err = resolve("label:env=dev"); err == nil {
+		t.Error("expected error for selector without matches")
+	}
+	if _, err = resolve("missing"); err == nil {
+		t.Error("expected error for unknown name")
+	}
+}
+
+func TestVerifyVolumesDetached(t *testing.T) {
+	free := &hcloud.Volume{ID: 1, Name: "data"}
+	attached := &hcloud.Volume{ID: 2, Name: "logs", Server: &hcloud.Server{ID: 42}}
+
+	if err := verifyVolumesDetached([]*hcloud.Volume{free}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := verifyVolumesDetached([]*hcloud.Volume{free, attached}); err == nil {
+		t.Error("expected error for volume attached to another server")
+	}
+}
+
+func TestFilterPlacementCandidates(t *testing.T) {
+	fsn1 := &hcloud.Location{Name: "fsn1", NetworkZone: hcloud.NetworkZoneEUCentral}
+	nbg1 := &hcloud.Location{Name: "nbg1", NetworkZone: hcloud.NetworkZoneEUCentral}
+	ash := &hcloud.Location{Name: "ash", NetworkZone: hcloud.NetworkZoneUSEast}
+	euNetwork := &hcloud.Network{Name: "eu", Subnets: []hcloud.NetworkSubnet{{NetworkZone: hcloud.NetworkZoneEUCentral}}}
+	usNetwork := &hcloud.Network{Name: "us", Subnets: []hcloud.NetworkSubnet{{NetworkZone: hcloud.NetworkZoneUSEast}}}
+	names := func(locations []*hcloud.Location) []string {
+		var result []string
+		for _, location := range locations {
+			result = append(result, locationName(location))
+		}
+		return result
+	}
+
+	tests := []struct {
+		name        string
+		candidates  []*hcloud.Location
+		volumes     []*hcloud.Volume
+		networks    []*hcloud.Network
+		expected    []string
+		expectError bool
+	}{
+		{"nothing attached", []*hcloud.Location{fsn1, ash}, nil, nil, []string{"fsn1", "ash"}, false},
+		{"network zone", []*hcloud.Location{ash, fsn1, nbg1}, nil, []*hcloud.Network{euNetwork}, []string{"fsn1", "nbg1"}, false},
+		{"volume location", []*hcloud.Location{fsn1, nbg1}, []*hcloud.Volume{{Name: "v", Location: nbg1}}, []*hcloud.Network{euNetwork}, []string{"nbg1"}, false},
+		{"volume location for any location", []*hcloud.Location{nil}, []*hcloud.Volume{{Name: "v", Location: nbg1}}, nil, []string{"nbg1"}, false},
+		{"any location with network", []*hcloud.Location{nil}, nil, []*hcloud.Network{usNetwork}, []string{"(any)"}, false},
+		{"volumes in different locations", []*hcloud.Location{nil}, []*hcloud.Volume{{Name: "v", Location: fsn1}, {Name: "w", Location: nbg1}}, nil, nil, true},
+		{"volume outside candidates", []*hcloud.Location{fsn1}, []*hcloud.Volume{{Name: "v", Location: ash}}, nil, nil, true},
+		{"network outside candidates", []*hcloud.Location{fsn1, nbg1}, nil, []*hcloud.Network{usNetwork}, nil, true},
+		{"networks in different zones", []*hcloud.Location{fsn1, ash}, nil, []*hcloud.Network{euNetwork, usNetwork}, nil, true},
+		{"volume and network in different zones", []*hcloud.Location{nil}, []*hcloud.Volume{{Name: "v", Location: ash}}, []*hcloud.Network{euNetwork}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usable, err := filterPlacementCandidates(tt.candidates, tt.volumes, tt.networks)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %v", names(usable))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(names(usable), tt.expected) {
+				t.Errorf("got %v, want %v", names(usable), tt.expected)
+			}
+		})
+	}
+}
+
+func TestDesiredServerLabels(t *testing.T) {
+	instanceLabel := config.LabelName(config.LabelInstanceID)
+	live := map[string]string{instanceLabel: "abc", "env": "dev", "manual": "yes"}
+	configured := map[string]string{"env": "prod", "role": "edge"}
+
+	desired := desiredServerLabels(live, configured, false)
+	expected := map[string]string{instanceLabel: "abc", "env": "prod", "role": "edge"}
+	if !maps.Equal(desired, expected) {
+		t.Errorf("got %v, want %v", desired, expected)
+	}
+
+	// labels of adopted servers are only ever added or updated
+	desired = desiredServerLabels(live, configured, true)
+	expected["manual"] = "yes"
+	if !maps.Equal(desired, expected) {
+		t.Errorf("got %v, want %v for adopted server", desired, expected)
+	}
+
+	if desired = desiredServerLabels(live, nil, false); !maps.Equal(desired, map[string]string{instanceLabel: "abc"}) {
+		t.Errorf("expected only driver labels to remain, got %v", desired)
+	}
+}
+
+func reconcileDescriptions(changes []reconcileChange) []string {
+	var descriptions []string
+	for _, change := range changes {
+		descriptions = append(descriptions, change.description)
+	}
+	return descriptions
+}
+
+func TestPlanFirewallChanges(t *testing.T) {
+	firewall := func(id int64, name, appliedTo string) string {
+		return fmt.Sprintf(`{"firewall": {"id": %d, "name": %q, "applied_to": [%s]}}`, id, name, appliedTo)
+	}
+	direct := `{"type": "server", "server": {"id": 1}}`
+	bySelector := `{"type": "label_selector", "label_selector": {"selector": "role=web"}, "applied_to_resources": [` + direct + `]}`
+	responses := map[string]string{
+		"GET /firewalls?name=web":   `{"firewalls": [{"id": 1, "name": "web"}]}`,
+		"GET /firewalls?name=extra": `{"firewalls": [{"id": 4, "name": "extra"}]}`,
+		"GET /firewalls/2":          firewall(2, "manual", direct),
+		"GET /firewalls/3":          firewall(3, "by-selector", bySelector),
+	}
+
+	srv := &hcloud.Server{ID: 1, Name: "node"}
+	for _, id := range []int64{1, 2, 3, 5} {
+		srv.PublicNet.Firewalls = append(srv.PublicNet.Firewalls, &hcloud.ServerFirewallStatus{Firewall: hcloud.Firewall{ID: id}})
+	}
+
+	tests := []struct {
+		name     string
+		adopted  bool
+		expected []string
+	}{
+		{"created", false, []string{"apply firewall extra [ID: 4]", "remove firewall manual [ID: 2]"}},
+		{"adopted", true, []string{"apply firewall extra [ID: 4]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			d.Firewalls = []string{"web", "extra"}
+			d.IsExistingServer = tt.adopted
+			// the Docker firewall is handled separately and must not be removed
+			d.DockerFirewall = true
+			d.DockerFirewallID = 5
+			d.cachedClient = testAPI(t, responses)
+
+			changes, err := d.planFirewallChanges(context.Background(), srv)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := reconcileDescriptions(changes); !slices.Equal(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPlanNetworkChanges(t *testing.T) {
+	responses := map[string]string{
+		"GET /networks?name=cluster": `{"networks": [{"id": 2, "name": "cluster"}]}`,
+		"GET /networks?name=mgmt":    `{"networks": [{"id": 3, "name": "mgmt"}]}`,
+		"GET /networks/7":            `{"network": {"id": 7, "name": "manual"}}`,
+	}
+	srv := &hcloud.Server{ID: 1, Name: "node", PrivateNet: []hcloud.ServerPrivateNet{
+		{Network: &hcloud.Network{ID: 3}, IP: net.ParseIP("10.2.0.2")},
+		{Network: &hcloud.Network{ID: 7}, IP: net.ParseIP("10.7.0.2")},
+	}}
+
+	tests := []struct {
+		name     string
+		adopted  bool
+		expected []string
+	}{
+		{"created", false, []string{"attach to network cluster [ID: 2]", "detach from network manual [ID: 7]"}},
+		{"adopted", true, []string{"attach to network cluster [ID: 2]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			if err := d.setNetworksFromFlags([]string{"cluster=10.1.0.5+10.1.0.6", "mgmt"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			d.IsExistingServer = tt.adopted
+			d.cachedClient = testAPI(t, responses)
+
+			changes, err := d.planNetworkChanges(context.Background(), srv)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := reconcileDescriptions(changes); !slices.Equal(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPlanDockerFirewallChanges(t *testing.T) {
+	t.Setenv(operatorIPEnv, "203.0.113.7")
+
+	d := NewDriver("test")
+	d.SSHPort = 22
+	d.DockerFirewall = true
+	d.OperatorIP = "198.51.100.1"
+	d.DockerFirewallID = 5
+	d.cachedClient = testAPI(t, map[string]string{
+		"GET /firewalls/5": `{"firewall": {"id": 5, "name": "node-docker", "applied_to": [], "rules": [
+			{"direction": "in", "protocol": "tcp", "port": "22", "source_ips": ["198.51.100.1/32"]},
+			{"direction": "in", "protocol": "tcp", "port": "2376", "source_ips": ["198.51.100.1/32"]}]}}`,
+	})
+
+	changes, err := d.planDockerFirewallChanges(context.Background(), &hcloud.Server{ID: 1, Name: "node"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"reset rules of Docker firewall node-docker [ID: 5]", "apply Docker firewall node-docker [ID: 5]"}
+	if result := reconcileDescriptions(changes); !slices.Equal(result, expected) {
+		t.Errorf("got %v, want %v", result, expected)
+	}
+	if d.OperatorIP != "203.0.113.7" {
+		t.Errorf("expected operator address from environment, got %v", d.OperatorIP)
+	}
+
+	d.DockerFirewallID = 6
+	changes, err = d.planDockerFirewallChanges(context.Background(), &hcloud.Server{ID: 1, Name: "node"})
+	if err != nil || len(changes) != 1 {
+		t.Errorf("expected deleted firewall to be re-created, got %v, %v", reconcileDescriptions(changes), err)
+	}
+}
+
+func TestLabelAdoptedServer(t *testing.T) {
+	d := NewDriver("test")
+	d.MachineName = "node"
+	d.ServerID = 1
+	d.InstanceID = "abc"
+	d.cachedClient = testAPI(t, map[string]string{
+		"GET /servers/1": `{"server": {"id": 1, "name": "hand-built", "labels": {"role": "web",
+			"docker-machine-driver-hetzner/machine-name": "node", "docker-machine-driver-hetzner/instance-id": "abc",
+			"docker-machine-driver-hetzner/adopted": "true"}}}`,
+		"PUT /servers/1": `{"server": {"id": 1, "name": "hand-built", "labels": {"role": "web"}}}`,
+	})
+
+	_, err := d.labelAdoptedServer(context.Background(), &hcloud.Server{ID: 1, Name: "hand-built", Labels: map[string]string{"role": "web"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		config.LabelName(config.LabelAdopted),
+		config.LabelName(config.LabelInstanceID),
+		config.LabelName(config.LabelMachineName),
+	}
+	if !slices.Equal(d.AddedServerLabels, expected) || len(d.dangling) != 1 {
+		t.Errorf("expected added labels %v to be recorded for rollback, got %v", expected, d.AddedServerLabels)
+	}
+
+	if err = d.unlabelAdoptedServer(context.Background()); err != nil || d.AddedServerLabels != nil {
+		t.Errorf("expected added labels to be removed, got %v, %v", d.AddedServerLabels, err)
+	}
+
+	// a server labelled before is not updated, and its labels are left alone on removal
+	d = NewDriver("test")
+	d.MachineName = "node"
+	d.InstanceID = "abc"
+	d.cachedClient = testAPI(t, nil)
+	_, err = d.labelAdoptedServer(context.Background(), &hcloud.Server{ID: 1, Labels: map[string]string{
+		config.LabelName(config.LabelMachineName): "node",
+		config.LabelName(config.LabelInstanceID):  "abc",
+		config.LabelName(config.LabelAdopted):     "true",
+	}})
+	if err != nil || d.AddedServerLabels != nil {
+		t.Errorf("expected labelled server to be left alone, got %v, %v", d.AddedServerLabels, err)
+	}
+}
+
+func TestVerifyVolumeNamesFree(t *testing.T) {
+	spec, err := parseVolumeSpec("size=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDriver("test")
+	d.MachineName = "node"
+	d.volumeSpecs = []volumeSpec{spec, spec}
+	d.cachedClient = testAPI(t, map[string]string{
+		"GET /volumes?name=node-volume-0": `{"volumes": []}`,
+		"GET /volumes?name=node-volume-1": `{"volumes": [{"id": 7, "name": "node-volume-1"}]}`,
+	})
+
+	// a volume kept from an earlier machine of the same name blocks the creation
+	if err = d.verifyVolumeNamesFree(context.Background()); err == nil || !strings.Contains(err.Error(), "node-volume-1") {
+		t.Errorf("expected error naming the existing volume, got %v", err)
+	}
+
+	d.volumeSpecs = d.volumeSpecs[:1]
+	if err = d.verifyVolumeNamesFree(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyResourcePlacementWithStaticNetwork(t *testing.T) {
+	d := NewDriver("test")
+	d.Networks = []string{"eu"}
+	d.StaticNetworks = map[string]*staticNetworkAddress{"eu": {IP: net.ParseIP("10.0.0.5")}}
+	d.cachedClient = testAPI(t, map[string]string{
+		"GET /networks?name=eu": `{"networks": [{"id": 1, "name": "eu", "subnets": [{"network_zone": "eu-central"}]}]}`,
+		"GET /locations": `{"locations": [{"id": 1, "name": "ash", "network_zone": "us-east"},
+			{"id": 2, "name": "fsn1", "network_zone": "eu-central"}, {"id": 3, "name": "nbg1", "network_zone": "eu-central"}]}`,
+	})
+
+	// without a location, the network attached after creation restricts the server to its zone
+	if err := d.verifyResourcePlacement(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, location := range d.cachedLocations {
+		names = append(names, locationName(location))
+	}
+	if expected := []string{"fsn1", "nbg1"}; !slices.Equal(names, expected) {
+		t.Errorf("got %v, want %v", names, expected)
+	}
+}
+
+func TestVerifyAddressStrategy(t *testing.T) {
+	d := NewDriver("test")
+	d.Networks = []string{"internal", "label:role=edge"}
+	d.AddressStrategy = []string{addressPrivateNet + "internal"}
+	d.cachedClient = testAPI(t, map[string]string{
+		"GET /networks?name=internal": `{"networks": [{"id": 1, "name": "internal"}]}`,
+	})
+
+	// a selector that cannot be resolved is reported instead of the network not being configured
+	err := d.verifyAddressStrategy(context.Background())
+	if err == nil || strings.Contains(err.Error(), "is not in") {
+		t.Errorf("expected resolution error, got %v", err)
+	}
+}
+
+func TestGetIPFallsBackOnSlowAPI(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	timeout := addressRefreshTimeout
+	addressRefreshTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { addressRefreshTimeout = timeout })
+
+	d := NewDriver("test")
+	d.ServerID = 1
+	d.IPAddress = "192.0.2.10"
+	d.cachedClient = hetzner.NewClient(hetzner.ClientConfig{AdditionalOpts: []hcloud.ClientOption{hcloud.WithEndpoint(api.URL)}})
+
+	start := time.Now()
+	ip, err := d.GetIP()
+	if err != nil || ip != "192.0.2.10" {
+		t.Errorf("expected stored address, got %v, %v", ip, err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("address refresh took %v despite its timeout", elapsed)
+	}
+}
+
+func TestVerifyCloudConfigUserData(t *testing.T) {
+	d := NewDriver("test")
+	d.userData = "#!/bin/sh\necho hello"
+	if err := d.verifyCloudConfigUserData(); err != nil {
+		t.Errorf("expected shell script to be accepted without driver cloud-config, got %v", err)
+	}
+
+	d.configureFloatingIPs = true
+	d.floatingIPs = []string{"ingress"}
+	if err := d.verifyCloudConfigUserData(); err == nil || !strings.Contains(err.Error(), flagFloatingIPConfig) {
+		t.Errorf("expected error naming --%v, got %v", flagFloatingIPConfig, err)
+	}
+
+	d.userData = "#cloud-config\npackages: [curl]"
+	if err := d.verifyCloudConfigUserData(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"go.yaml.in/yaml/v2"
+)
+
+var firewallProtocols = []string{
+	string(hcloud.FirewallRuleProtocolTCP),
+	string(hcloud.FirewallRuleProtocolUDP),
+	string(hcloud.FirewallRuleProtocolICMP),
+	string(hcloud.FirewallRuleProtocolESP),
+	string(hcloud.FirewallRuleProtocolGRE),
+}
+
+// firewallRulesFile is the format of --hetzner-firewall-rules-file; being YAML, it also accepts JSON
+type firewallRulesFile struct {
+	Rules []firewallRuleSpec `yaml:"rules"`
+}
+
+type firewallRuleSpec struct {
+	Direction      string   `yaml:"direction"`
+	Protocol       string   `yaml:"protocol"`
+	Port           string   `yaml:"port"`
+	SourceIPs      []string `yaml:"source_ips"`
+	DestinationIPs []string `yaml:"destination_ips"`
+	Description    string   `yaml:"description"`
+}
+
+// parseFirewallRules validates the rules of a rules file and converts them to their API representation
+func parseFirewallRules(buf []byte) ([]hcloud.FirewallRule, error) {
+	var file firewallRulesFile
+	if err := yaml.UnmarshalStrict(buf, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("no rules defined")
+	}
+
+	rules := make([]hcloud.FirewallRule, 0, len(file.Rules))
+	for i, spec := range file.Rules {
+		rule, err := spec.toFirewallRule()
+		if err != nil {
+			return nil, fmt.Errorf("rule #%d: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (spec firewallRuleSpec) toFirewallRule() (hcloud.FirewallRule, error) {
+	rule := hcloud.FirewallRule{
+		Direction: hcloud.FirewallRuleDirectionIn,
+		Protocol:  hcloud.FirewallRuleProtocol(spec.Protocol),
+	}
+	if spec.Description != "" {
+		rule.Description = hcloud.Ptr(spec.Description)
+	}
+
+	switch spec.Direction {
+	case "", string(hcloud.FirewallRuleDirectionIn):
+	case string(hcloud.FirewallRuleDirectionOut):
+		rule.Direction = hcloud.FirewallRuleDirectionOut
+	default:
+		return rule, fmt.Errorf("direction must be in or out, got %q", spec.Direction)
+	}
+
+	if !slices.Contains(firewallProtocols, spec.Protocol) {
+		return rule, fmt.Errorf("protocol must be one of %v, got %q", firewallProtocols, spec.Protocol)
+	}
+
+	hasPort := rule.Protocol == hcloud.FirewallRuleProtocolTCP || rule.Protocol == hcloud.FirewallRuleProtocolUDP
+	if hasPort != (spec.Port != "") {
+		if hasPort {
+			return rule, fmt.Errorf("%v rules require a port", spec.Protocol)
+		}
+		return rule, fmt.Errorf("%v rules do not take a port", spec.Protocol)
+	}
+	if hasPort {
+		if err := verifyFirewallPort(spec.Port); err != nil {
+			return rule, err
+		}
+		rule.Port = hcloud.Ptr(spec.Port)
+	}
+
+	var err error
+	if rule.SourceIPs, err = parseFirewallCIDRs(spec.SourceIPs); err != nil {
+		return rule, fmt.Errorf("source_ips: %w", err)
+	}
+	if rule.DestinationIPs, err = parseFirewallCIDRs(spec.DestinationIPs); err != nil {
+		return rule, fmt.Errorf("destination_ips: %w", err)
+	}
+
+	if rule.Direction == hcloud.FirewallRuleDirectionIn && (len(rule.SourceIPs) == 0 || len(rule.DestinationIPs) != 0) {
+		return rule, fmt.Errorf("inbound rules require source_ips and take no destination_ips")
+	}
+	if rule.Direction == hcloud.FirewallRuleDirectionOut && (len(rule.DestinationIPs) == 0 || len(rule.SourceIPs) != 0) {
+		return rule, fmt.Errorf("outbound rules require destination_ips and take no source_ips")
+	}
+	return rule, nil
+}
+
+// verifyFirewallPort accepts a single port, a range like 8000-9000 or `any`
+func verifyFirewallPort(port string) error {
+	if port == "any" {
+		return nil
+	}
+
+	lower, upper, isRange := strings.Cut(port, "-")
+	first, err := strconv.ParseUint(lower, 10, 16)
+	if err == nil && isRange {
+		var last uint64
+		if last, err = strconv.ParseUint(upper, 10, 16); err == nil && last < first {
+			err = fmt.Errorf("empty range")
+		}
+	}
+	if err != nil || first == 0 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+func parseFirewallCIDRs(raw []string) ([]net.IPNet, error) {
+	var cidrs []net.IPNet
+	for _, cidr := range raw {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, *ipNet)
+	}
+	return cidrs, nil
+}
+
+func (d *Driver) setFirewallRulesFromFlags(path, name string) error {
+	if path == "" {
+		if name != "" {
+			return d.flagFailure("--%v requires --%v", flagFirewallName, flagFirewallRules)
+		}
+		return nil
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return d.flagFailure("--%v: could not read rules file: %v", flagFirewallRules, err)
+	}
+	if d.firewallRules, err = parseFirewallRules(buf); err != nil {
+		return d.flagFailure("--%v: %v", flagFirewallRules, err)
+	}
+
+	d.firewallName = name
+	if d.firewallName == "" {
+		d.firewallName = d.GetMachineName() + "-firewall"
+	}
+	if d.ExistingServer != "" {
+		return d.flagFailure("--%v and --%v are mutually exclusive", flagFirewallRules, flagExistingServer)
+	}
+	return nil
+}
+
+// getRulesFirewall returns the firewall described by the rules file, creating it unless a firewall of that name
+// exists already, e.g. because it is shared with other machines. The rules of an existing firewall created by the
+// driver are replaced if the rules file changed; other firewalls must have the same rules.
+func (d *Driver) getRulesFirewall(ctx context.Context) (*hcloud.Firewall, error) {
+	if d.cachedRulesFirewall != nil || len(d.firewallRules) == 0 {
+		return d.cachedRulesFirewall, nil
+	}
+
+	firewall, err := d.getClient().GetFirewallByName(ctx, d.firewallName)
+	if err != nil {
+		return nil, err
+	}
+	if firewall == nil {
+		if firewall, err = d.makeFirewall(ctx, d.firewallName, d.firewallRules, nil); err != nil {
+			return nil, err
+		}
+	} else if err = d.syncRulesFirewall(ctx, firewall); err != nil {
+		return nil, err
+	}
+
+	d.RulesFirewallID = firewall.ID
+	d.cachedRulesFirewall = instrumented(firewall)
+	return d.cachedRulesFirewall, nil
+}
+
+// syncRulesFirewall brings the rules of an existing firewall in line with the rules file
+func (d *Driver) syncRulesFirewall(ctx context.Context, firewall *hcloud.Firewall) error {
+	if firewallRulesEqual(firewall.Rules, d.firewallRules) {
+		log.Debugf("Using existing firewall %s", logging.Key(firewall.Name, firewall.ID))
+		return nil
+	}
+	if firewall.Labels[config.LabelName(config.LabelAutoCreated)] != "true" {
+		return fmt.Errorf("firewall %s exists with rules differing from --%v, but was not created by the driver; "+
+			"update it or choose another --%v", logging.Key(firewall.Name, firewall.ID), flagFirewallRules, flagFirewallName)
+	}
+
+	logging.Step("Updating rules of firewall %s from --%v", logging.Key(firewall.Name, firewall.ID), flagFirewallRules)
+	actions, err := d.getClient().SetFirewallRules(ctx, firewall, d.firewallRules)
+	if err != nil {
+		return err
+	}
+	if err = d.waitForMultipleActions(ctx, "firewall.SetRules", actions); err != nil {
+		return fmt.Errorf("could not wait for firewall rules: %w", err)
+	}
+	return nil
+}
+
+// makeFirewall creates a firewall during machine creation, registering it for removal should creation fail
+func (d *Driver) makeFirewall(ctx context.Context, name string, rules []hcloud.FirewallRule, labels map[string]string) (*hcloud.Firewall, error) {
+	firewall, err := d.createFirewall(ctx, name, rules, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	d.journalRecord(journalKindFirewall, firewall.ID, firewall.Name)
+	d.dangling = append(d.dangling, func(ctx context.Context) {
+		err := d.getClient().DeleteFirewall(ctx, firewall)
+		if err != nil {
+			log.Errorf("Could not delete firewall: %v", err)
+			return
+		}
+		d.journalForget(journalKindFirewall, firewall.ID)
+	})
+
+	return instrumented(firewall), nil
+}
+
+// createFirewall creates a firewall owned by the machine, with the given labels in addition to the driver's own; unlike
+// makeFirewall, it is not rolled back on failure, so it suits firewalls created outside machine creation
+func (d *Driver) createFirewall(ctx context.Context, name string, rules []hcloud.FirewallRule, labels map[string]string) (*hcloud.Firewall, error) {
+	merged := map[string]string{config.LabelName(config.LabelAutoCreated): "true"}
+	maps.Copy(merged, labels)
+
+	logging.Step("Creating firewall %s with %d rule(s)...", name, len(rules))
+	firewall, err := d.getClient().CreateFirewall(ctx, instrumented(hcloud.FirewallCreateOpts{
+		Name:   name,
+		Rules:  rules,
+		Labels: d.withOwnershipLabels(merged),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return instrumented(firewall), nil
+}
+
+// removeUnusedFirewall deletes a firewall created by the driver once it is not applied to any server anymore,
+// regardless of which machine created it
+func (d *Driver) removeUnusedFirewall(ctx context.Context, id int64) {
+	if id == 0 {
+		return
+	}
+
+	firewall, err := d.getClient().GetFirewallByID(ctx, id)
+	if err != nil {
+		logging.WarnStep("Could not get firewall [ID: %d]: %v", id, err)
+		return
+	}
+	if firewall == nil || firewall.Labels[config.LabelName(config.LabelAutoCreated)] != "true" {
+		return
+	}
+	if len(firewall.AppliedTo) != 0 {
+		log.Debugf("Firewall %s still applied to %d resource(s), skipping cleanup", logging.Key(firewall.Name, firewall.ID), len(firewall.AppliedTo))
+		return
+	}
+
+	logging.Step("Destroying unused firewall %s", logging.Key(firewall.Name, firewall.ID))
+	// failure to remove a firewall is not a hard error
+	if err = d.getClient().DeleteFirewall(ctx, firewall); err != nil {
+		logging.WarnStep("Could not remove firewall: %v", err)
+	}
+}
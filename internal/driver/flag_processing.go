@@ -41,6 +41,10 @@ func (d *Driver) verifyNetworkFlags() error {
 			flagUsePrivateNetwork, flagDisablePublic)
 	}
 
+	if d.DisablePublic4 && d.DisablePublic6 && len(d.Networks) != 0 && len(d.StaticNetworks) == len(d.Networks) {
+		return d.flagFailure("--%v requires at least one network without fixed address if public networking is disabled", flagNetworks)
+	}
+
 	if d.DisablePublic4 && d.PrimaryIPv4 != "" {
 		return d.flagFailure("--%v and --%v are mutually exclusive", flagPrimary4, flagDisablePublic4)
 	}
@@ -51,6 +55,18 @@ func (d *Driver) verifyNetworkFlags() error {
 	return nil
 }
 
+func (d *Driver) verifyExistingServerFlags() error {
+	if d.rebuildExisting && d.ExistingServer == "" {
+		return d.flagFailure("--%v requires --%v", flagRebuildExisting, flagExistingServer)
+	}
+
+	// keys cannot be added to a running server through the API, so access must already be possible
+	if d.ExistingServer != "" && !d.rebuildExisting && d.originalKey == "" {
+		return d.flagFailure("--%v requires --%v or --%v", flagExistingServer, flagExKeyPath, flagRebuildExisting)
+	}
+	return nil
+}
+
 func (d *Driver) deprecatedBooleanFlag(opts drivers.DriverOptions, flag, deprecatedFlag string) bool {
 	if opts.Bool(deprecatedFlag) {
 		log.Warnf("DEPRECATED: --%s will be removed, use --%s instead", deprecatedFlag, flag)
@@ -105,5 +121,24 @@ func (d *Driver) setLabelsFromFlags(opts drivers.DriverOptions) error {
 		}
 		d.keyLabels[split[0]] = split[1]
 	}
+	d.networkLabels = make(map[string]string)
+	for _, label := range opts.StringSlice(flagNetworkLabel) {
+		split := strings.SplitN(label, "=", 2)
+		if len(split) != 2 {
+			return d.flagFailure("network label %v is not in key=value format", label)
+		}
+		d.networkLabels[split[0]] = split[1]
+	}
 	return nil
 }
+
+// splitList splits a comma-separated flag value, dropping surrounding whitespace and empty items
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -1,6 +1,9 @@
 package driver
 
 import (
+	"net"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
@@ -253,6 +256,375 @@ func TestVerifyNetworkFlags(t *testing.T) {
 	}
 }
 
+func TestVerifyExistingServerFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		existingServer string
+		rebuild        bool
+		keyPath        string
+		expectError    bool
+	}{
+		{"no existing server", "", false, "", false},
+		{"existing server with key path", "my-server", false, "/tmp/key", false},
+		{"existing server with rebuild", "12345", true, "", false},
+		{"existing server without key access", "my-server", false, "", true},
+		{"rebuild without existing server", "", true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			d.ExistingServer = tt.existingServer
+			d.rebuildExisting = tt.rebuild
+			d.originalKey = tt.keyPath
+
+			err := d.verifyExistingServerFlags()
+			if tt.expectError && err == nil {
+				t.Error("expected error, got nil")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyNamedPrimaryIPFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		named       bool
+		keep        bool
+		autoDelete  bool
+		expectError bool
+	}{
+		{"disabled", false, false, false, false},
+		{"named", true, false, false, false},
+		{"named and kept", true, true, false, false},
+		{"named with auto-delete", true, false, true, false},
+		{"kept without named", false, true, false, true},
+		{"auto-delete without named", false, false, true, true},
+		{"kept with auto-delete", true, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			d.namedPrimaryIPs = tt.named
+			d.KeepPrimaryIPs = tt.keep
+			d.primaryIPAutoDelete = tt.autoDelete
+
+			err := d.verifyNamedPrimaryIPFlags()
+			if tt.expectError && err == nil {
+				t.Error("expected error, got nil")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyFloatingIPFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		floatingIPs    []string
+		configure      bool
+		release        bool
+		existingServer string
+		expectError    bool
+	}{
+		{"none", nil, false, false, "", false},
+		{"floating IPs with options", []string{"ingress"}, true, true, "", false},
+		{"configure without floating IPs", nil, true, false, "", true},
+		{"release without floating IPs", nil, false, true, "", true},
+		{"floating IPs with existing server", []string{"ingress"}, false, false, "my-server", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			d.floatingIPs = tt.floatingIPs
+			d.configureFloatingIPs = tt.configure
+			d.ReleaseFloatingIPs = tt.release
+			d.ExistingServer = tt.existingServer
+
+			err := d.verifyFloatingIPFlags()
+			if tt.expectError && err == nil {
+				t.Error("expected error, got nil")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyDockerFirewallFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		cidrs          []string
+		operatorIP     string
+		icmp           bool
+		existingServer string
+		expectError    bool
+	}{
+		{"disabled", false, nil, "", false, "", false},
+		{"CIDRs", true, []string{"192.0.2.0/24"}, "", true, "", false},
+		{"operator IP", true, nil, "203.0.113.7", false, "", false},
+		{"no sources", true, nil, "", false, "", true},
+		{"invalid CIDR", true, []string{"192.0.2.1"}, "", false, "", true},
+		{"invalid operator IP", true, nil, "203.0.113.0/24", false, "", true},
+		{"sources without firewall", false, nil, "203.0.113.7", false, "", true},
+		{"ICMP without firewall", false, nil, "", true, "", true},
+		{"existing server", true, nil, "203.0.113.7", false, "my-server", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDriver("test")
+			d.DockerFirewall = tt.enabled
+			d.DockerFirewallCIDRs = tt.cidrs
+			d.OperatorIP = tt.operatorIP
+			d.DockerFirewallICMP = tt.icmp
+			d.ExistingServer = tt.existingServer
+
+			err := d.verifyDockerFirewallFlags()
+			if tt.expectError && err == nil {
+				t.Error("expected error, got nil")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestUsesNamedPrimaryIP(t *testing.T) {
+	d := NewDriver("test")
+	d.MachineName = "node-1"
+	d.namedPrimaryIPs = true
+	d.PrimaryIPv6 = "existing-ipv6"
+
+	if !d.usesNamedPrimaryIP(hcloud.PrimaryIPTypeIPv4) {
+		t.Error("expected named IPv4")
+	}
+	if d.usesNamedPrimaryIP(hcloud.PrimaryIPTypeIPv6) {
+		t.Error("expected explicit IPv6 to take precedence")
+	}
+	if name := d.namedPrimaryIPName(hcloud.PrimaryIPTypeIPv4); name != "node-1-ipv4" {
+		t.Errorf("unexpected name %v", name)
+	}
+
+	d.DisablePublic4 = true
+	if d.usesNamedPrimaryIP(hcloud.PrimaryIPTypeIPv4) {
+		t.Error("expected no named IPv4 with public IPv4 disabled")
+	}
+}
+
+func TestParseVolumeSpec(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		expectError  bool
+		expectedSize int
+		expectedName string
+	}{
+		{"size only", "size=10", false, 10, "machine-volume-3"},
+		{"all keys", "size=50: name={{.MachineName}}-data: format=xfs: automount=true", false, 50, "machine-data"},
+		{"missing size", "name=data", true, 0, ""},
+		{"too small", "size=5", true, 0, ""},
+		{"unknown format", "size=10:format=btrfs", true, 0, ""},
+		{"unknown key", "size=10:foo=bar", true, 0, ""},
+		{"not key=value", "size=10:automount", true, 0, ""},
+		{"comma-separated", "size=10,name=data", true, 0, ""},
+		{"unknown template field", "size=10:name={{.Foo}}", true, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseVolumeSpec(tt.raw)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if spec.Size != tt.expectedSize {
+				t.Errorf("size = %d, want %d", spec.Size, tt.expectedSize)
+			}
+			if name, _ := spec.name("machine", 3); name != tt.expectedName {
+				t.Errorf("name = %q, want %q", name, tt.expectedName)
+			}
+		})
+	}
+}
+
+func TestCreateVolumeFromEnvironment(t *testing.T) {
+	// like the docker-machine CLI, split the value of HETZNER_CREATE_VOLUMES at commas into a string slice
+	env := "size=50:name={{.MachineName}}-data:format=xfs, size=10"
+	var volumes []string
+	for _, item := range strings.Split(env, ",") {
+		volumes = append(volumes, strings.TrimSpace(item))
+	}
+
+	d := NewDriver("test")
+	err := d.setConfigFromFlagsImpl(makeFlags(map[string]interface{}{
+		flagCreateVolume: volumes,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.volumeSpecs) != 2 || d.volumeSpecs[0].Size != 50 || d.volumeSpecs[0].Format != "xfs" || d.volumeSpecs[1].Size != 10 {
+		t.Errorf("unexpected volume specs %+v", d.volumeSpecs)
+	}
+}
+
+func TestParseNetworkRanges(t *testing.T) {
+	tests := []struct {
+		name           string
+		ipRange        string
+		subnet         string
+		expectError    bool
+		expectedSubnet string
+	}{
+		{"range only", "10.0.0.0/16", "", false, "10.0.0.0/16"},
+		{"subnet within range", "10.0.0.0/16", "10.0.1.0/24", false, "10.0.1.0/24"},
+		{"subnet outside range", "10.0.0.0/16", "10.1.0.0/24", true, ""},
+		{"subnet larger than range", "10.0.0.0/16", "10.0.0.0/8", true, ""},
+		{"invalid range", "10.0.0.0", "", true, ""},
+		{"invalid subnet", "10.0.0.0/16", "foo", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, subnet, err := parseNetworkRanges(tt.ipRange, tt.subnet)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if subnet.String() != tt.expectedSubnet {
+				t.Errorf("subnet = %v, want %v", subnet, tt.expectedSubnet)
+			}
+		})
+	}
+}
+
+func TestParseNetworkAttachment(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		expectError   bool
+		expectedName  string
+		expectedIP    string
+		expectedAlias []string
+	}{
+		{"plain name", "my-network", false, "my-network", "", nil},
+		{"plain ID", "1234", false, "1234", "", nil},
+		{"fixed IP", "my-network=10.0.0.5", false, "my-network", "10.0.0.5", nil},
+		{"fixed IP with aliases", "my-network=10.0.0.5+10.0.0.6+10.0.0.7", false, "my-network", "10.0.0.5", []string{"10.0.0.6", "10.0.0.7"}},
+		{"comma-separated aliases", "my-network=10.0.0.5,10.0.0.6", true, "", "", nil},
+		{"missing IP", "my-network=", true, "", "", nil},
+		{"invalid IP", "my-network=10.0.0", true, "", "", nil},
+		{"IPv6", "my-network=fd00::1", true, "", "", nil},
+		{"label selector", "label:env=prod,role=edge", false, "label:env=prod,role=edge", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, addr, err := parseNetworkAttachment(tt.raw)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != tt.expectedName {
+				t.Errorf("name = %v, want %v", name, tt.expectedName)
+			}
+			if tt.expectedIP == "" {
+				if addr != nil {
+					t.Errorf("expected no fixed address, got %v", addr.IP)
+				}
+				return
+			}
+			if addr == nil || addr.IP.String() != tt.expectedIP {
+				t.Fatalf("address = %v, want %v", addr, tt.expectedIP)
+			}
+			if len(addr.AliasIPs) != len(tt.expectedAlias) {
+				t.Fatalf("aliases = %v, want %v", addr.AliasIPs, tt.expectedAlias)
+			}
+			for i, alias := range addr.AliasIPs {
+				if alias.String() != tt.expectedAlias[i] {
+					t.Errorf("alias %d = %v, want %v", i, alias, tt.expectedAlias[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNetworkContains(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("10.0.1.0/24")
+	network := &hcloud.Network{Subnets: []hcloud.NetworkSubnet{{IPRange: subnet}}}
+
+	if !networkContains(network, net.ParseIP("10.0.1.5")) {
+		t.Error("expected 10.0.1.5 to be within subnet")
+	}
+	if networkContains(network, net.ParseIP("10.0.2.5")) {
+		t.Error("expected 10.0.2.5 to be outside subnet")
+	}
+}
+
+func TestParseFirewallRules(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		expectError   bool
+		expectedRules int
+	}{
+		{"yaml", "rules:\n- protocol: tcp\n  port: 22\n  source_ips: [0.0.0.0/0, \"::/0\"]\n- protocol: icmp\n  source_ips: [10.0.0.0/8]\n", false, 2},
+		{"json", `{"rules": [{"direction": "in", "protocol": "tcp", "port": "8000-9000", "source_ips": ["192.0.2.0/24"]}]}`, false, 1},
+		{"outbound", "rules:\n- direction: out\n  protocol: udp\n  port: any\n  destination_ips: [0.0.0.0/0]\n", false, 1},
+		{"no rules", "rules: []\n", true, 0},
+		{"unknown field", "rules:\n- protocol: tcp\n  ports: 22\n  source_ips: [0.0.0.0/0]\n", true, 0},
+		{"invalid direction", "rules:\n- direction: both\n  protocol: icmp\n  source_ips: [0.0.0.0/0]\n", true, 0},
+		{"invalid protocol", "rules:\n- protocol: sctp\n  source_ips: [0.0.0.0/0]\n", true, 0},
+		{"missing port", "rules:\n- protocol: tcp\n  source_ips: [0.0.0.0/0]\n", true, 0},
+		{"port on icmp", "rules:\n- protocol: icmp\n  port: 22\n  source_ips: [0.0.0.0/0]\n", true, 0},
+		{"invalid port", "rules:\n- protocol: tcp\n  port: 70000\n  source_ips: [0.0.0.0/0]\n", true, 0},
+		{"empty range", "rules:\n- protocol: tcp\n  port: 90-80\n  source_ips: [0.0.0.0/0]\n", true, 0},
+		{"invalid CIDR", "rules:\n- protocol: tcp\n  port: 22\n  source_ips: [10.0.0.1]\n", true, 0},
+		{"inbound without sources", "rules:\n- protocol: tcp\n  port: 22\n", true, 0},
+		{"outbound with sources", "rules:\n- direction: out\n  protocol: gre\n  source_ips: [0.0.0.0/0]\n  destination_ips: [0.0.0.0/0]\n", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseFirewallRules([]byte(tt.raw))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %v", rules)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rules) != tt.expectedRules {
+				t.Errorf("got %d rules, want %d", len(rules), tt.expectedRules)
+			}
+		})
+	}
+}
+
 func TestSetLabelsFromFlags(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -426,3 +798,25 @@ func TestDeprecatedBooleanFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"single", "fsn1", []string{"fsn1"}},
+		{"multiple", "fsn1,nbg1,hel1", []string{"fsn1", "nbg1", "hel1"}},
+		{"whitespace and empties", " fsn1, ,nbg1 ,", []string{"fsn1", "nbg1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := splitList(tt.raw)
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("splitList(%q) = %v, want %v", tt.raw, result, tt.expected)
+			}
+		})
+	}
+}
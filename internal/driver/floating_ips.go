@@ -0,0 +1,200 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// floatingIPScript binds a floating IP to the interface holding the default route on every boot
+const floatingIPScript = `ip %[1]s addr replace %[2]s dev "$(ip %[1]s route show default | awk '{ print $5; exit }')"`
+
+func (d *Driver) verifyFloatingIPFlags() error {
+	if len(d.floatingIPs) == 0 && (d.configureFloatingIPs || d.ReleaseFloatingIPs) {
+		return d.flagFailure("--%v and --%v require --%v", flagFloatingIPConfig, flagFloatingIPRelease, flagFloatingIPs)
+	}
+	if len(d.floatingIPs) != 0 && d.ExistingServer != "" {
+		return d.flagFailure("--%v and --%v are mutually exclusive", flagFloatingIPs, flagExistingServer)
+	}
+	return nil
+}
+
+// getFloatingIPs resolves the floating IPs to assign. A label selector picks the first matching floating IP that is
+// not assigned to any server yet, so a pool of addresses can be shared by several machines.
+func (d *Driver) getFloatingIPs(ctx context.Context) ([]*hcloud.FloatingIP, error) {
+	if d.cachedFloatingIPs != nil || len(d.floatingIPs) == 0 {
+		return d.cachedFloatingIPs, nil
+	}
+
+	var ips []*hcloud.FloatingIP
+	seen := make(map[int64]bool)
+	for _, raw := range d.floatingIPs {
+		ip, err := d.resolveFloatingIP(ctx, raw, seen)
+		if err != nil {
+			return nil, err
+		}
+		if seen[ip.ID] {
+			continue
+		}
+		seen[ip.ID] = true
+		ips = append(ips, ip)
+	}
+
+	d.cachedFloatingIPs = instrumented(ips)
+	return d.cachedFloatingIPs, nil
+}
+
+func (d *Driver) resolveFloatingIP(ctx context.Context, raw string, taken map[int64]bool) (*hcloud.FloatingIP, error) {
+	selector, isSelector := strings.CutPrefix(raw, labelSelectorPrefix)
+	if !isSelector {
+		ip, err := d.getClient().GetFloatingIP(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+		if ip.Server != nil {
+			logging.WarnStep("Floating IP %s is assigned to server [ID: %d] and will be reassigned", logging.Key(ip.Name, ip.ID), ip.Server.ID)
+		}
+		return ip, nil
+	}
+
+	candidates, err := d.getClient().GetFloatingIPsByLabel(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range candidates {
+		if ip.Server == nil && !taken[ip.ID] {
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the %d floating IPs matching %v is unassigned", len(candidates), selector)
+}
+
+// floatingIPAddress returns the address bound on the host: the floating IPv4 itself, or ::1 within a floating /64
+func floatingIPAddress(ip *hcloud.FloatingIP) string {
+	if ip.Type == hcloud.FloatingIPTypeIPv4 {
+		return ip.IP.String() + "/32"
+	}
+
+	host := make(net.IP, net.IPv6len)
+	copy(host, ip.Network.IP.To16())
+	host[net.IPv6len-1] |= 0x01
+	ones, _ := ip.Network.Mask.Size()
+	return fmt.Sprintf("%v/%d", host, ones)
+}
+
+// setFloatingIPConfig injects a cloud-init `bootcmd` binding the floating IPs on the host, if requested
+func (d *Driver) setFloatingIPConfig(ctx context.Context, srvopts *hcloud.ServerCreateOpts) error {
+	if !d.configureFloatingIPs {
+		return nil
+	}
+
+	ips, err := d.getFloatingIPs(ctx)
+	if err != nil || len(ips) == 0 {
+		return err
+	}
+
+	var commands []interface{}
+	for _, ip := range ips {
+		family := "-4"
+		if ip.Type == hcloud.FloatingIPTypeIPv6 {
+			family = "-6"
+		}
+		commands = append(commands, []string{"sh", "-c", fmt.Sprintf(floatingIPScript, family, floatingIPAddress(ip))})
+	}
+
+	if err = addCloudConfig(srvopts, map[string]interface{}{"bootcmd": commands}); err != nil {
+		return fmt.Errorf("could not add floating IP configuration to user data: %w", err)
+	}
+	return nil
+}
+
+// assignFloatingIPs assigns the resolved floating IPs to the newly created server
+func (d *Driver) assignFloatingIPs(ctx context.Context, srv *hcloud.Server) error {
+	ips, err := d.getFloatingIPs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range ips {
+		if err = d.assignFloatingIP(ctx, ip, srv); err != nil {
+			return err
+		}
+		d.FloatingIPIDs = append(d.FloatingIPIDs, ip.ID)
+	}
+	return nil
+}
+
+func (d *Driver) assignFloatingIP(ctx context.Context, ip *hcloud.FloatingIP, srv *hcloud.Server) error {
+	logging.Step("Assigning floating IP %s (%v)", logging.Key(ip.Name, ip.ID), ip.IP)
+	action, err := d.getClient().AssignFloatingIP(ctx, ip, srv)
+	if err != nil {
+		return err
+	}
+	if err = d.waitForAction(ctx, action); err != nil {
+		return fmt.Errorf("could not wait for floating IP assignment: %w", err)
+	}
+	return nil
+}
+
+// reassignFloatingIPs assigns floating IPs released by Stop to the server again, unless they were taken over by
+// another server in the meantime
+func (d *Driver) reassignFloatingIPs(ctx context.Context, srv *hcloud.Server) error {
+	if !d.ReleaseFloatingIPs {
+		return nil
+	}
+
+	for _, id := range d.FloatingIPIDs {
+		ip, err := d.getClient().GetFloatingIPByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if ip == nil {
+			logging.WarnStep("Floating IP [ID: %d] no longer exists", id)
+			continue
+		}
+		if ip.Server != nil {
+			if ip.Server.ID != srv.ID {
+				logging.WarnStep("Floating IP %s is assigned to server [ID: %d], not reassigning", logging.Key(ip.Name, ip.ID), ip.Server.ID)
+			}
+			continue
+		}
+
+		if err = d.assignFloatingIP(ctx, ip, srv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseFloatingIPs unassigns the machine's floating IPs, if requested, so they can fail over to other servers
+func (d *Driver) releaseFloatingIPs(ctx context.Context) {
+	if !d.ReleaseFloatingIPs {
+		return
+	}
+
+	for _, id := range d.FloatingIPIDs {
+		ip, err := d.getClient().GetFloatingIPByID(ctx, id)
+		if err != nil {
+			logging.WarnStep("Could not get floating IP [ID: %d]: %v", id, err)
+			continue
+		}
+		if ip == nil || ip.Server == nil || ip.Server.ID != d.ServerID {
+			continue
+		}
+
+		logging.Step("Unassigning floating IP %s (%v)", logging.Key(ip.Name, ip.ID), ip.IP)
+		action, err := d.getClient().UnassignFloatingIP(ctx, ip)
+		if err == nil {
+			err = d.waitForAction(ctx, action)
+		}
+
+		// failure to release a floating IP is not a hard error
+		if err != nil {
+			logging.WarnStep("Could not unassign floating IP [ID: %d]: %v", id, err)
+		}
+	}
+}
@@ -15,43 +15,47 @@ func (d *Driver) getClient() *hetzner.Client {
 	}
 
 	d.cachedClient = hetzner.NewClient(hetzner.ClientConfig{
-		Token:          d.AccessToken,
-		AppName:        "docker-machine-driver",
-		AppVersion:     d.version,
-		PollInterval:   time.Duration(d.WaitOnPolling) * time.Second,
+		Token:        d.AccessToken,
+		AppName:      "docker-machine-driver",
+		AppVersion:   d.version,
+		PollInterval: time.Duration(d.WaitOnPolling) * time.Second,
+		Retry: hetzner.RetryPolicy{
+			MaxRetries: d.APIRetries,
+			BaseDelay:  time.Duration(d.APIRetryBackoff) * time.Second,
+		},
 		AdditionalOpts: d.getClientInstrumentationOpts(),
 	})
 
 	return d.cachedClient
 }
 
-func (d *Driver) getLocationNullable() (*hcloud.Location, error) {
+func (d *Driver) getLocationNullable(ctx context.Context) (*hcloud.Location, error) {
 	if d.cachedLocation != nil {
 		return d.cachedLocation, nil
 	}
 
-	location, err := d.getClient().GetLocation(context.Background(), d.Location)
+	candidates, err := d.getLocationCandidates(ctx)
 	if err != nil {
 		return nil, err
 	}
-	d.cachedLocation = location
-	return location, nil
+	d.cachedLocation = candidates[0]
+	return d.cachedLocation, nil
 }
 
-func (d *Driver) getType() (*hcloud.ServerType, error) {
+func (d *Driver) getType(ctx context.Context) (*hcloud.ServerType, error) {
 	if d.cachedType != nil {
 		return d.cachedType, nil
 	}
 
-	stype, err := d.getClient().GetServerType(context.Background(), d.Type)
+	candidates, err := d.getTypeCandidates(ctx)
 	if err != nil {
 		return nil, err
 	}
-	d.cachedType = stype
-	return instrumented(stype), nil
+	d.cachedType = candidates[0]
+	return d.cachedType, nil
 }
 
-func (d *Driver) getImage() (*hcloud.Image, error) {
+func (d *Driver) getImage(ctx context.Context) (*hcloud.Image, error) {
 	if d.cachedImage != nil {
 		return d.cachedImage, nil
 	}
@@ -60,17 +64,17 @@ func (d *Driver) getImage() (*hcloud.Image, error) {
 	var err error
 
 	if d.ImageID != 0 {
-		image, err = d.getClient().GetImageByID(context.Background(), d.ImageID)
+		image, err = d.getClient().GetImageByID(ctx, d.ImageID)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		arch, err := d.getImageArchitectureForLookup()
+		arch, err := d.getImageArchitectureForLookup(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("could not determine image architecture: %w", err)
 		}
 
-		image, err = d.getClient().GetImageByNameAndArch(context.Background(), d.Image, arch)
+		image, err = d.getClient().GetImageByNameAndArch(ctx, d.Image, arch)
 		if err != nil {
 			return nil, err
 		}
@@ -80,12 +84,12 @@ func (d *Driver) getImage() (*hcloud.Image, error) {
 	return instrumented(image), nil
 }
 
-func (d *Driver) getImageArchitectureForLookup() (hcloud.Architecture, error) {
+func (d *Driver) getImageArchitectureForLookup(ctx context.Context) (hcloud.Architecture, error) {
 	if d.ImageArch != emptyImageArchitecture {
 		return d.ImageArch, nil
 	}
 
-	serverType, err := d.getType()
+	serverType, err := d.getType(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -93,8 +97,8 @@ func (d *Driver) getImageArchitectureForLookup() (hcloud.Architecture, error) {
 	return serverType.Architecture, nil
 }
 
-func (d *Driver) getKey() (*hcloud.SSHKey, error) {
-	key, err := d.getKeyNullable()
+func (d *Driver) getKey(ctx context.Context) (*hcloud.SSHKey, error) {
+	key, err := d.getKeyNullable(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -104,12 +108,12 @@ func (d *Driver) getKey() (*hcloud.SSHKey, error) {
 	return key, err
 }
 
-func (d *Driver) getKeyNullable() (*hcloud.SSHKey, error) {
+func (d *Driver) getKeyNullable(ctx context.Context) (*hcloud.SSHKey, error) {
 	if d.cachedKey != nil {
 		return d.cachedKey, nil
 	}
 
-	key, err := d.getClient().GetSSHKeyByID(context.Background(), d.KeyID)
+	key, err := d.getClient().GetSSHKeyByID(ctx, d.KeyID)
 	if err != nil {
 		return nil, err
 	}
@@ -117,16 +121,16 @@ func (d *Driver) getKeyNullable() (*hcloud.SSHKey, error) {
 	return instrumented(key), nil
 }
 
-func (d *Driver) getRemoteKeyWithSameFingerprintNullable(publicKeyBytes []byte) (*hcloud.SSHKey, error) {
-	remoteKey, err := d.getClient().GetSSHKeyByPublicKey(context.Background(), publicKeyBytes)
+func (d *Driver) getRemoteKeyWithSameFingerprintNullable(ctx context.Context, publicKeyBytes []byte) (*hcloud.SSHKey, error) {
+	remoteKey, err := d.getClient().GetSSHKeyByPublicKey(ctx, publicKeyBytes)
 	if err != nil {
 		return nil, err
 	}
 	return instrumented(remoteKey), nil
 }
 
-func (d *Driver) getServerHandle() (*hcloud.Server, error) {
-	srv, err := d.getServerHandleNullable()
+func (d *Driver) getServerHandle(ctx context.Context) (*hcloud.Server, error) {
+	srv, err := d.getServerHandleNullable(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -136,12 +140,12 @@ func (d *Driver) getServerHandle() (*hcloud.Server, error) {
 	return srv, nil
 }
 
-func (d *Driver) getServerHandleNullable() (*hcloud.Server, error) {
+func (d *Driver) getServerHandleNullable(ctx context.Context) (*hcloud.Server, error) {
 	if d.cachedServer != nil {
 		return d.cachedServer, nil
 	}
 
-	srv, err := d.getClient().GetServerByID(context.Background(), d.ServerID)
+	srv, err := d.lookupServer(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -150,10 +154,16 @@ func (d *Driver) getServerHandleNullable() (*hcloud.Server, error) {
 	return srv, nil
 }
 
-func (d *Driver) waitForAction(a *hcloud.Action) error {
-	return d.getClient().WaitForAction(context.Background(), a)
+func (d *Driver) waitForAction(ctx context.Context, a *hcloud.Action) error {
+	ctx, cancel := withTimeout(ctx, d.ActionTimeout, errActionTimeout)
+	defer cancel()
+
+	return contextError(ctx, d.getClient().WaitForAction(ctx, a))
 }
 
-func (d *Driver) waitForMultipleActions(step string, a []*hcloud.Action) error {
-	return d.getClient().WaitForActions(context.Background(), step, a)
+func (d *Driver) waitForMultipleActions(ctx context.Context, step string, a []*hcloud.Action) error {
+	ctx, cancel := withTimeout(ctx, d.ActionTimeout, errActionTimeout)
+	defer cancel()
+
+	return contextError(ctx, d.getClient().WaitForActions(ctx, step, a))
 }
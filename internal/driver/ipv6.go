@@ -0,0 +1,116 @@
+package driver
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	ipv6HostRandom      = "random"
+	ipv6HostMachineName = "machine-name"
+)
+
+// defaultIPv6InterfaceID is the host address Hetzner configures within the server's /64
+var defaultIPv6InterfaceID = net.ParseIP("::1")
+
+// ipv6HostScript adds the address with the given interface identifier to the interface holding the /64 configured by
+// Hetzner. The prefix is only known once the server exists, so it is derived from the configured address on every boot.
+const ipv6HostScript = `set -- $(ip -6 -o addr show scope global | awk '$4 ~ /\/64$/ { sub("/64", "", $4); print $2, $4; exit }')
+[ -n "$2" ] || exit 0
+prefix=$(echo "$2" | awk -F'::' '{ n = split($1, l, ":"); m = (NF > 1 && $2 != "") ? split($2, r, ":") : 0; for (i = 1; i <= 4; i++) printf "%%s%%s", (i <= n ? l[i] : (i > 8 - m ? r[i - 8 + m] : 0)), (i < 4 ? ":" : "") }')
+ip -6 addr replace "$prefix:%s/64" dev "$1"
+`
+
+// resolveIPv6InterfaceID turns the value of --hetzner-ipv6-host into the lower 64 bits of the machine's IPv6 address:
+// either `random`, `machine-name` (a stable hash of the name) or a literal identifier such as `::10`
+func resolveIPv6InterfaceID(raw, machineName string) (net.IP, error) {
+	iid := make(net.IP, net.IPv6len)
+	switch raw {
+	case "":
+		return defaultIPv6InterfaceID, nil
+	case ipv6HostRandom:
+		if _, err := rand.Read(iid[net.IPv6len/2:]); err != nil {
+			return nil, fmt.Errorf("could not generate interface identifier: %w", err)
+		}
+	case ipv6HostMachineName:
+		sum := sha256.Sum256([]byte(machineName))
+		copy(iid[net.IPv6len/2:], sum[:])
+	default:
+		iid = net.ParseIP(raw)
+		if iid == nil || iid.To4() != nil {
+			return nil, fmt.Errorf("expected %v, %v or an IPv6 interface identifier like ::10, got %q",
+				ipv6HostRandom, ipv6HostMachineName, raw)
+		}
+		if binary.BigEndian.Uint64(iid[:net.IPv6len/2]) != 0 {
+			return nil, fmt.Errorf("interface identifier %v exceeds the lower 64 bits", iid)
+		}
+	}
+
+	if binary.BigEndian.Uint64(iid[net.IPv6len/2:]) == 0 {
+		return nil, fmt.Errorf("interface identifier must not be zero")
+	}
+	return iid, nil
+}
+
+// getIPv6InterfaceID returns the interface identifier chosen on creation; configurations predating the option use ::1
+func (d *Driver) getIPv6InterfaceID() net.IP {
+	if iid := net.ParseIP(d.IPv6InterfaceID); iid != nil {
+		return iid
+	}
+	return defaultIPv6InterfaceID
+}
+
+// usesCustomIPv6Host reports whether the machine's IPv6 address differs from the one configured by Hetzner
+func (d *Driver) usesCustomIPv6Host() bool {
+	return !d.getIPv6InterfaceID().Equal(defaultIPv6InterfaceID)
+}
+
+func (d *Driver) verifyIPv6HostFlags() error {
+	if !d.usesCustomIPv6Host() {
+		return nil
+	}
+	if d.DisablePublic6 && d.PrimaryIPv6 == "" {
+		return d.flagFailure("--%v requires a public IPv6 network", flagIPv6Host)
+	}
+	if d.ExistingServer != "" {
+		return d.flagFailure("--%v and --%v are mutually exclusive", flagIPv6Host, flagExistingServer)
+	}
+	return nil
+}
+
+// ipv6HostAddress returns the machine's address within the given IPv6 network
+func (d *Driver) ipv6HostAddress(network *net.IPNet) net.IP {
+	prefix := network.IP.To16()
+	iid := d.getIPv6InterfaceID()
+
+	ip := make(net.IP, net.IPv6len)
+	for i := range ip {
+		ip[i] = prefix[i]&network.Mask[i] | iid[i]&^network.Mask[i]
+	}
+	return ip
+}
+
+// setIPv6Host injects a cloud-init `bootcmd` configuring the chosen IPv6 address on the host
+func (d *Driver) setIPv6Host(srvopts *hcloud.ServerCreateOpts) error {
+	if !d.usesCustomIPv6Host() {
+		return nil
+	}
+
+	iid := d.getIPv6InterfaceID()
+	suffix := fmt.Sprintf("%x:%x:%x:%x",
+		binary.BigEndian.Uint16(iid[8:]), binary.BigEndian.Uint16(iid[10:]),
+		binary.BigEndian.Uint16(iid[12:]), binary.BigEndian.Uint16(iid[14:]))
+
+	err := addCloudConfig(srvopts, map[string]interface{}{
+		"bootcmd": []interface{}{[]string{"sh", "-c", fmt.Sprintf(ipv6HostScript, suffix)}},
+	})
+	if err != nil {
+		return fmt.Errorf("could not add IPv6 host configuration to user data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/docker/machine/libmachine/log"
+)
+
+// journalFile is kept next to the machine config and lists resources created by a Create that has not completed
+// yet, so they can be removed even if the plugin process was killed midway
+const journalFile = "hetzner-journal.json"
+
+const (
+	journalKindSSHKey         = "ssh-key"
+	journalKindPlacementGroup = "placement-group"
+	journalKindServer         = "server"
+	journalKindVolume         = "volume"
+	journalKindNetwork        = "network"
+	journalKindFirewall       = "firewall"
+	journalKindPrimaryIP      = "primary-ip"
+	journalKindRetainedIP     = "retained-primary-ip"
+)
+
+type journalEntry struct {
+	Kind string `json:"kind"`
+	ID   int64  `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+func (d *Driver) journalPath() string {
+	if d.StorePath == "" {
+		return ""
+	}
+	return d.ResolveStorePath(journalFile)
+}
+
+func (d *Driver) readJournal() ([]journalEntry, error) {
+	path := d.journalPath()
+	if path == "" {
+		return nil, nil
+	}
+
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read journal: %w", err)
+	}
+
+	var entries []journalEntry
+	if err = json.Unmarshal(buf, &entries); err != nil {
+		return nil, fmt.Errorf("could not parse journal: %w", err)
+	}
+	return entries, nil
+}
+
+// writeJournal atomically replaces the journal, removing it once empty
+func (d *Driver) writeJournal(entries []journalEntry) error {
+	path := d.journalPath()
+	if path == "" {
+		return nil
+	}
+
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not remove journal: %w", err)
+		}
+		return nil
+	}
+
+	buf, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode journal: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("could not create journal directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, buf, 0600); err != nil {
+		return fmt.Errorf("could not write journal: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("could not replace journal: %w", err)
+	}
+	return nil
+}
+
+// journalRecord persists a newly created resource; failing to do so is not fatal, as the in-memory
+// dangling list still covers the regular failure paths
+func (d *Driver) journalRecord(kind string, id int64, name string) {
+	entries, err := d.readJournal()
+	if err == nil {
+		err = d.writeJournal(append(entries, journalEntry{Kind: kind, ID: id, Name: name}))
+	}
+	if err != nil {
+		log.Warnf("Could not record %s [ID: %d] in journal: %v", kind, id, err)
+	}
+}
+
+// journalForget drops a resource that no longer needs to be cleaned up
+func (d *Driver) journalForget(kind string, id int64) {
+	entries, err := d.readJournal()
+	if err == nil {
+		entries = slices.DeleteFunc(entries, func(e journalEntry) bool {
+			return e.Kind == kind && e.ID == id
+		})
+		err = d.writeJournal(entries)
+	}
+	if err != nil {
+		log.Warnf("Could not remove %s [ID: %d] from journal: %v", kind, id, err)
+	}
+}
+
+// clearJournal forgets all recorded resources, e.g. once they are tracked by the machine config
+func (d *Driver) clearJournal() {
+	if err := d.writeJournal(nil); err != nil {
+		log.Warnf("Could not clear journal: %v", err)
+	}
+}
+
+// replayJournal removes all resources left over by an interrupted Create; resources that could not
+// be removed stay listed
+func (d *Driver) replayJournal(ctx context.Context) error {
+	entries, err := d.readJournal()
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	logging.Step("Cleaning up %d resource(s) left over by an interrupted creation...", len(entries))
+
+	var remaining []journalEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if err := d.destroyJournalEntry(ctx, entry); err != nil {
+			logging.WarnStep("Could not remove %s %s: %v", entry.Kind, logging.Key(entry.Name, entry.ID), err)
+			remaining = append([]journalEntry{entry}, remaining...)
+		}
+	}
+
+	return d.writeJournal(remaining)
+}
+
+func (d *Driver) destroyJournalEntry(ctx context.Context, entry journalEntry) error {
+	client := d.getClient()
+
+	switch entry.Kind {
+	case journalKindServer:
+		srv, err := client.GetServerByID(ctx, entry.ID)
+		if err != nil || srv == nil {
+			return err
+		}
+		logging.Substep("Destroying %s", logging.Server(srv.Name, srv.ID))
+		action, err := client.DeleteServer(ctx, srv)
+		if err != nil {
+			return err
+		}
+		if d.ServerID == srv.ID {
+			d.ServerID = 0
+			d.cachedServer = nil
+		}
+		return d.waitForAction(ctx, action)
+	case journalKindVolume:
+		logging.Substep("Destroying volume %s", logging.Key(entry.Name, entry.ID))
+		return d.destroyVolume(ctx, entry.ID)
+	case journalKindNetwork:
+		return d.destroyUnusedNetwork(ctx, entry.ID)
+	case journalKindFirewall:
+		firewall, err := client.GetFirewallByID(ctx, entry.ID)
+		if err != nil || firewall == nil {
+			return err
+		}
+		logging.Substep("Destroying firewall %s", logging.Key(firewall.Name, firewall.ID))
+		return client.DeleteFirewall(ctx, firewall)
+	case journalKindPrimaryIP, journalKindRetainedIP:
+		return d.restorePrimaryIP(ctx, entry.ID, entry.Kind == journalKindRetainedIP)
+	case journalKindPlacementGroup:
+		grp, err := client.GetPlacementGroup(ctx, strconv.FormatInt(entry.ID, 10))
+		if err != nil || grp == nil {
+			return err
+		}
+		logging.Substep("Destroying placement group %s", logging.Key(grp.Name, grp.ID))
+		return client.DeletePlacementGroup(ctx, grp)
+	case journalKindSSHKey:
+		key, err := client.GetSSHKeyByID(ctx, entry.ID)
+		if err != nil || key == nil {
+			return err
+		}
+		logging.Substep("Destroying SSH key %s", logging.Key(key.Name, key.ID))
+		return client.DeleteSSHKey(ctx, key)
+	default:
+		return fmt.Errorf("unknown journal entry kind: %v", entry.Kind)
+	}
+}
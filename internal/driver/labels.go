@@ -0,0 +1,39 @@
+package driver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+)
+
+// getInstanceID returns the unique ID of this machine, generating it on first use
+func (d *Driver) getInstanceID() string {
+	if d.InstanceID == "" {
+		buf := make([]byte, 8)
+		_, _ = rand.Read(buf) // never returns an error
+		d.InstanceID = hex.EncodeToString(buf)
+	}
+	return d.InstanceID
+}
+
+// ownershipLabels identifies resources created for this machine, so they can be found even if the machine config is lost
+func (d *Driver) ownershipLabels() map[string]string {
+	return map[string]string{
+		config.LabelName(config.LabelMachineName):   config.LabelValue(d.GetMachineName()),
+		config.LabelName(config.LabelInstanceID):    d.getInstanceID(),
+		config.LabelName(config.LabelDriverVersion): config.LabelValue(d.version),
+		config.LabelName(config.LabelCreatedAt):     strconv.FormatInt(time.Now().Unix(), 10),
+	}
+}
+
+// withOwnershipLabels merges user-supplied labels with ownership labels, the latter taking precedence
+func (d *Driver) withOwnershipLabels(labels map[string]string) map[string]string {
+	merged := make(map[string]string, len(labels)+4)
+	maps.Copy(merged, labels)
+	maps.Copy(merged, d.ownershipLabels())
+	return merged
+}
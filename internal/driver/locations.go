@@ -0,0 +1,155 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/hetzner"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+var errLocationConflict = errors.New("location conflicts with location-bound resource")
+
+// getLocationCandidates resolves the configured locations and network zones into the ordered list of
+// locations server creation is attempted in; a single nil entry lets Hetzner choose the location
+func (d *Driver) getLocationCandidates(ctx context.Context) ([]*hcloud.Location, error) {
+	if d.cachedLocations != nil {
+		return d.cachedLocations, nil
+	}
+
+	names := splitList(d.Location)
+	if len(names) == 0 {
+		d.cachedLocations = []*hcloud.Location{nil}
+		return d.cachedLocations, nil
+	}
+
+	all, err := d.getClient().GetLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := expandLocationCandidates(names, all)
+	if err != nil {
+		return nil, err
+	}
+
+	d.cachedLocations = candidates
+	return instrumented(candidates), nil
+}
+
+// expandLocationCandidates maps location names and network zones onto known locations, keeping the
+// given order and dropping duplicates
+func expandLocationCandidates(names []string, all []*hcloud.Location) ([]*hcloud.Location, error) {
+	var candidates []*hcloud.Location
+	seen := make(map[int64]bool)
+	add := func(location *hcloud.Location) {
+		if !seen[location.ID] {
+			seen[location.ID] = true
+			candidates = append(candidates, location)
+		}
+	}
+
+	for _, name := range names {
+		found := false
+		for _, location := range all {
+			if location.Name == name {
+				add(location)
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+
+		for _, location := range all {
+			if string(location.NetworkZone) == name {
+				add(location)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown location or network zone: %v", name)
+		}
+	}
+
+	return candidates, nil
+}
+
+// verifyLocationBoundResources rejects a location when volumes or primary IPs passed in srvopts reside elsewhere
+func verifyLocationBoundResources(srvopts *hcloud.ServerCreateOpts) error {
+	location := srvopts.Location
+	if location == nil {
+		return nil
+	}
+
+	for _, volume := range srvopts.Volumes {
+		if volume.Location != nil && volume.Location.Name != location.Name {
+			return fmt.Errorf("%w: volume %v is in %v", errLocationConflict, volume.Name, volume.Location.Name)
+		}
+	}
+
+	if srvopts.PublicNet != nil {
+		for _, ip := range []*hcloud.PrimaryIP{srvopts.PublicNet.IPv4, srvopts.PublicNet.IPv6} {
+			if ip == nil || ip.Datacenter == nil || ip.Datacenter.Location == nil {
+				continue
+			}
+			if ip.Datacenter.Location.Name != location.Name {
+				return fmt.Errorf("%w: primary IP %v is in %v", errLocationConflict, ip.Name, ip.Datacenter.Location.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func locationName(location *hcloud.Location) string {
+	if location == nil {
+		return "(any)"
+	}
+	return location.Name
+}
+
+// createServerInCandidateLocations attempts server creation in each candidate location in order, moving on
+// to the next one when Hetzner lacks capacity or the location conflicts with location-bound resources
+func (d *Driver) createServerInCandidateLocations(ctx context.Context) (hcloud.ServerCreateResult, error) {
+	candidates, err := d.getLocationCandidates(ctx)
+	if err != nil {
+		return hcloud.ServerCreateResult{}, fmt.Errorf("could not get location: %w", err)
+	}
+
+	var lastErr error
+	for i, location := range candidates {
+		// location-bound resources are re-resolved for every candidate
+		d.cachedLocation = location
+		d.cachedPrimaryIPv4 = nil
+		d.cachedPrimaryIPv6 = nil
+
+		srvopts, err := d.makeCreateServerOptions(ctx)
+		if errors.Is(err, errLocationConflict) {
+			logging.WarnStep("Skipping location %s: %v", locationName(location), err)
+			lastErr = err
+			continue
+		} else if err != nil {
+			return hcloud.ServerCreateResult{}, err
+		}
+
+		srv, err := d.getClient().CreateServer(ctx, instrumented(*srvopts))
+		if err == nil {
+			if location != nil {
+				d.Location = location.Name
+			}
+			return srv, nil
+		}
+
+		if !hetzner.IsUnavailableError(err) || i == len(candidates)-1 {
+			return hcloud.ServerCreateResult{}, err
+		}
+
+		logging.WarnStep("Location %s is unavailable, trying next candidate: %v", locationName(location), err)
+		lastErr = err
+	}
+
+	return hcloud.ServerCreateResult{}, fmt.Errorf("no usable location candidate: %w", lastErr)
+}
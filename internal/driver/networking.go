@@ -3,53 +3,88 @@ package driver
 import (
 	"context"
 	"fmt"
-	"net"
-	"time"
+	"strings"
 
 	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
-func (d *Driver) getPrimaryIPv4() (*hcloud.PrimaryIP, error) {
+func (d *Driver) getPrimaryIPv4(ctx context.Context) (*hcloud.PrimaryIP, error) {
 	raw := d.PrimaryIPv4
-	if raw == "" {
-		return nil, nil
-	} else if d.cachedPrimaryIPv4 != nil {
+	if d.cachedPrimaryIPv4 != nil {
 		return d.cachedPrimaryIPv4, nil
+	} else if d.usesNamedPrimaryIP(hcloud.PrimaryIPTypeIPv4) {
+		ip, err := d.getNamedPrimaryIP(ctx, hcloud.PrimaryIPTypeIPv4)
+		d.cachedPrimaryIPv4 = ip
+		return ip, err
+	} else if raw == "" {
+		return nil, nil
 	}
 
-	ip, err := d.resolvePrimaryIP(raw)
+	ip, err := d.resolvePrimaryIP(ctx, raw)
 	d.cachedPrimaryIPv4 = ip
 	return ip, err
 }
 
-func (d *Driver) getPrimaryIPv6() (*hcloud.PrimaryIP, error) {
+func (d *Driver) getPrimaryIPv6(ctx context.Context) (*hcloud.PrimaryIP, error) {
 	raw := d.PrimaryIPv6
-	if raw == "" {
-		return nil, nil
-	} else if d.cachedPrimaryIPv6 != nil {
+	if d.cachedPrimaryIPv6 != nil {
 		return d.cachedPrimaryIPv6, nil
+	} else if d.usesNamedPrimaryIP(hcloud.PrimaryIPTypeIPv6) {
+		ip, err := d.getNamedPrimaryIP(ctx, hcloud.PrimaryIPTypeIPv6)
+		d.cachedPrimaryIPv6 = ip
+		return ip, err
+	} else if raw == "" {
+		return nil, nil
 	}
 
-	ip, err := d.resolvePrimaryIP(raw)
+	ip, err := d.resolvePrimaryIP(ctx, raw)
 	d.cachedPrimaryIPv6 = ip
 	return ip, err
 }
 
-func (d *Driver) resolvePrimaryIP(raw string) (*hcloud.PrimaryIP, error) {
-	ip, err := d.getClient().GetPrimaryIP(context.Background(), raw)
+func (d *Driver) resolvePrimaryIP(ctx context.Context, raw string) (*hcloud.PrimaryIP, error) {
+	ip, err := d.getClient().GetPrimaryIP(ctx, raw)
 	if err != nil {
 		return nil, err
 	}
 	return instrumented(ip), nil
 }
 
-func (d *Driver) setPublicNetIfRequired(srvopts *hcloud.ServerCreateOpts) error {
-	pip4, err := d.getPrimaryIPv4()
+// getNetworkCached resolves a network by ID or name once per driver invocation, as networks are looked up repeatedly,
+// e.g. while polling for the address during creation
+func (d *Driver) getNetworkCached(ctx context.Context, nameOrID string) (*hcloud.Network, error) {
+	if network, ok := d.cachedNetworks[nameOrID]; ok {
+		return network, nil
+	}
+
+	network, err := d.getClient().GetNetwork(ctx, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	if d.cachedNetworks == nil {
+		d.cachedNetworks = make(map[string]*hcloud.Network)
+	}
+	d.cachedNetworks[nameOrID] = instrumented(network)
+	return d.cachedNetworks[nameOrID], nil
+}
+
+// selectPrivateNet returns the server's attachment to the given network, or its first attachment if network is nil
+func selectPrivateNet(server *hcloud.Server, network *hcloud.Network) *hcloud.ServerPrivateNet {
+	for i, privateNet := range server.PrivateNet {
+		if network == nil || (privateNet.Network != nil && privateNet.Network.ID == network.ID) {
+			return &server.PrivateNet[i]
+		}
+	}
+	return nil
+}
+
+func (d *Driver) setPublicNetIfRequired(ctx context.Context, srvopts *hcloud.ServerCreateOpts) error {
+	pip4, err := d.getPrimaryIPv4(ctx)
 	if err != nil {
 		return err
 	}
-	pip6, err := d.getPrimaryIPv6()
+	pip6, err := d.getPrimaryIPv6(ctx)
 	if err != nil {
 		return err
 	}
@@ -65,35 +100,32 @@ func (d *Driver) setPublicNetIfRequired(srvopts *hcloud.ServerCreateOpts) error
 	return nil
 }
 
-func (d *Driver) configureNetworkAccess(srv hcloud.ServerCreateResult) error {
-	if d.UsePrivateNetwork {
-		logging.Step("Waiting for private network attachment...")
-		for {
-			server, err := d.getClient().GetServerByID(context.Background(), srv.Server.ID)
-			if err != nil {
-				return fmt.Errorf("could not get newly created server [%d]: %w", srv.Server.ID, err)
-			}
-			if server.PrivateNet != nil {
-				d.IPAddress = server.PrivateNet[0].IP.String()
-				logging.Substep("Private network attached: %s", d.IPAddress)
-				break
-			}
-			time.Sleep(time.Duration(d.WaitOnPolling) * time.Second)
-		}
-	} else if d.DisablePublic4 {
-		logging.Step("Configuring public IPv6 network...")
+func (d *Driver) configureNetworkAccess(ctx context.Context, srv hcloud.ServerCreateResult) error {
+	strategy := d.getAddressStrategy()
+	logging.Step("Resolving machine address (%v)...", strings.Join(strategy, ","))
 
-		pv6 := srv.Server.PublicNet.IPv6
-		ip := pv6.IP
-		if ip.Mask(pv6.Network.Mask).Equal(pv6.Network.IP) { // no host given
-			ip[net.IPv6len-1] |= 0x01 // TODO make this configurable
+	ctx, cancel := withTimeout(ctx, d.WaitForNetworkTimeout, errWaitForNetworkTimeout)
+	defer cancel()
+
+	server := srv.Server
+	for {
+		ip, err := d.selectAddress(ctx, server, strategy, true)
+		if err != nil {
+			return err
+		}
+		if ip != "" {
+			d.IPAddress = ip
+			logging.Substep("Using address %s", d.IPAddress)
+			return nil
 		}
 
-		d.IPAddress = ip.String()
-		logging.Substep("Resolved IPv6: %s", d.IPAddress)
-	} else {
-		logging.Step("Using public IPv4 network...")
-		d.IPAddress = srv.Server.PublicNet.IPv4.IP.String()
+		logging.DebugStep("Waiting for private network attachment...")
+		if err = d.waitPolling(ctx); err != nil {
+			return err
+		}
+		server, err = d.getClient().GetServerByID(ctx, srv.Server.ID)
+		if err != nil {
+			return fmt.Errorf("could not get newly created server [%d]: %w", srv.Server.ID, contextError(ctx, err))
+		}
 	}
-	return nil
 }
@@ -0,0 +1,301 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/hetzner"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func isNumericID(nameOrID string) bool {
+	_, err := strconv.ParseInt(nameOrID, 10, 64)
+	return err == nil
+}
+
+// networkAliasSeparator separates the alias IPs following the fixed IP of an attached network
+const networkAliasSeparator = "+"
+
+// staticNetworkAddress is a fixed address, plus optional alias addresses, requested for an attached network
+type staticNetworkAddress struct {
+	IP       net.IP
+	AliasIPs []net.IP
+}
+
+// parseNetworkAttachment splits a network reference of the form `network[=ip[+alias...]]`; label selectors cannot
+// carry fixed addresses. Aliases are not separated by commas, as environment variables of list flags are split at
+// commas.
+func parseNetworkAttachment(raw string) (string, *staticNetworkAddress, error) {
+	if isLabelSelector(raw) {
+		return raw, nil, nil
+	}
+
+	name, addresses, found := strings.Cut(raw, "=")
+	name = strings.TrimSpace(name)
+	if !found {
+		return name, nil, nil
+	}
+
+	var ips []net.IP
+	for _, item := range strings.Split(addresses, networkAliasSeparator) {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		ip := net.ParseIP(item)
+		if ip == nil || ip.To4() == nil {
+			return "", nil, fmt.Errorf("invalid private IPv4 address %q for network %v", item, name)
+		}
+		ips = append(ips, ip)
+	}
+	if len(ips) == 0 {
+		return "", nil, fmt.Errorf("missing IP address for network %v", name)
+	}
+
+	return name, &staticNetworkAddress{IP: ips[0], AliasIPs: ips[1:]}, nil
+}
+
+func (d *Driver) setNetworksFromFlags(raw []string) error {
+	d.Networks = nil
+	d.StaticNetworks = make(map[string]*staticNetworkAddress)
+	for _, item := range raw {
+		name, addr, err := parseNetworkAttachment(item)
+		if err != nil {
+			return d.flagFailure("--%v: %v", flagNetworks, err)
+		}
+		d.Networks = append(d.Networks, name)
+		if addr != nil {
+			d.StaticNetworks[name] = addr
+		}
+	}
+	return nil
+}
+
+// networkContains reports whether ip lies within one of the network's subnets
+func networkContains(network *hcloud.Network, ip net.IP) bool {
+	for _, subnet := range network.Subnets {
+		if subnet.IPRange != nil && subnet.IPRange.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyStaticNetworkAddresses ensures fixed addresses fit into the subnets of their networks
+func (d *Driver) verifyStaticNetworkAddresses(ctx context.Context) error {
+	for _, name := range d.Networks {
+		addr, ok := d.StaticNetworks[name]
+		if !ok {
+			continue
+		}
+
+		network, err := d.getNetworkCached(ctx, name)
+		if err != nil {
+			return err
+		}
+		for _, ip := range append([]net.IP{addr.IP}, addr.AliasIPs...) {
+			if !networkContains(network, ip) {
+				return fmt.Errorf("%v is not within a subnet of network %v", ip, network.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// attachStaticNetworks attaches the server to networks with fixed addresses, which cannot be requested on creation
+func (d *Driver) attachStaticNetworks(ctx context.Context, srv *hcloud.Server) error {
+	for _, name := range d.Networks {
+		addr, ok := d.StaticNetworks[name]
+		if !ok {
+			continue
+		}
+
+		network, err := d.getNetworkCached(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		logging.Step("Attaching to network %s with %v", logging.Key(network.Name, network.ID), addr.IP)
+		action, err := d.getClient().AttachServerToNetwork(ctx, srv, instrumented(hcloud.ServerAttachToNetworkOpts{
+			Network:  network,
+			IP:       addr.IP,
+			AliasIPs: addr.AliasIPs,
+		}))
+		if err != nil {
+			return err
+		}
+		if err = d.waitForAction(ctx, action); err != nil {
+			return fmt.Errorf("could not wait for network attachment: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseNetworkRanges validates the IP range of networks to create and the subnet servers are placed in, which
+// defaults to the whole range
+func parseNetworkRanges(ipRange, subnet string) (*net.IPNet, *net.IPNet, error) {
+	_, rangeNet, err := net.ParseCIDR(ipRange)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid IP range: %w", err)
+	}
+	if subnet == "" {
+		return rangeNet, rangeNet, nil
+	}
+
+	_, subnetNet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid subnet: %w", err)
+	}
+
+	rangeOnes, _ := rangeNet.Mask.Size()
+	subnetOnes, _ := subnetNet.Mask.Size()
+	if !rangeNet.Contains(subnetNet.IP) || subnetOnes < rangeOnes {
+		return nil, nil, fmt.Errorf("subnet %v is not within IP range %v", subnetNet, rangeNet)
+	}
+	return rangeNet, subnetNet, nil
+}
+
+// getNetworkZone returns the zone of subnets in created networks, defaulting to the zone of the first candidate location
+func (d *Driver) getNetworkZone(ctx context.Context) (hcloud.NetworkZone, error) {
+	if d.networkZone != "" {
+		return d.networkZone, nil
+	}
+
+	candidates, err := d.getLocationCandidates(ctx)
+	if err != nil {
+		return "", err
+	}
+	if candidates[0] != nil {
+		return candidates[0].NetworkZone, nil
+	}
+	return hcloud.NetworkZoneEUCentral, nil
+}
+
+// ensureNetworks creates networks referenced by name that do not exist yet, if requested
+func (d *Driver) ensureNetworks(ctx context.Context) error {
+	if !d.autoCreateNetworks {
+		return nil
+	}
+
+	for _, name := range d.Networks {
+		if isNumericID(name) || isLabelSelector(name) {
+			// IDs and selectors always refer to existing networks
+			continue
+		}
+
+		network, err := d.getClient().GetNetworkByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if network != nil {
+			continue
+		}
+
+		zone, err := d.getNetworkZone(ctx)
+		if err != nil {
+			return fmt.Errorf("could not determine network zone: %w", err)
+		}
+		if _, err = d.makeNetwork(ctx, name, zone); err == nil {
+			continue
+		} else if !hetzner.IsUniquenessError(err) {
+			return err
+		}
+
+		// another machine created the network in the meantime, join it instead
+		network, err = d.getClient().GetNetworkByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if network == nil {
+			return fmt.Errorf("network %v was created concurrently, but could not be found", name)
+		}
+		logging.Substep("Network %s was created concurrently, joining it", logging.Key(network.Name, network.ID))
+	}
+	return nil
+}
+
+func (d *Driver) makeNetwork(ctx context.Context, name string, zone hcloud.NetworkZone) (*hcloud.Network, error) {
+	labels := maps.Clone(d.networkLabels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[config.LabelName(config.LabelAutoCreated)] = "true"
+
+	logging.Step("Creating network %s (%v, subnet %v in %v)...", name, d.networkIPRange, d.networkSubnet, zone)
+	network, err := d.getClient().CreateNetwork(ctx, instrumented(hcloud.NetworkCreateOpts{
+		Name:    name,
+		IPRange: d.networkIPRange,
+		Subnets: []hcloud.NetworkSubnet{{
+			Type:        hcloud.NetworkSubnetTypeCloud,
+			IPRange:     d.networkSubnet,
+			NetworkZone: zone,
+		}},
+		Labels: d.withOwnershipLabels(labels),
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	d.journalRecord(journalKindNetwork, network.ID, network.Name)
+	d.dangling = append(d.dangling, func(ctx context.Context) {
+		err := d.destroyUnusedNetwork(ctx, network.ID)
+		if err != nil {
+			log.Errorf("Could not delete network: %v", err)
+			return
+		}
+		d.journalForget(journalKindNetwork, network.ID)
+	})
+
+	return instrumented(network), nil
+}
+
+// destroyUnusedNetwork deletes a network created by the driver, unless other machines attached to it in the meantime:
+// deleting a network detaches all its servers
+func (d *Driver) destroyUnusedNetwork(ctx context.Context, id int64) error {
+	network, err := d.getClient().GetNetworkByID(ctx, id)
+	if err != nil || network == nil {
+		return err
+	}
+	if len(network.Servers) != 0 {
+		logging.Substep("Keeping network %s, still in use by %d server(s)", logging.Key(network.Name, network.ID), len(network.Servers))
+		return nil
+	}
+
+	logging.Substep("Destroying network %s", logging.Key(network.Name, network.ID))
+	return d.getClient().DeleteNetwork(ctx, network)
+}
+
+// removeEmptyAutoCreatedNetworks deletes networks created by the driver once no server is attached anymore,
+// regardless of which machine created them
+func (d *Driver) removeEmptyAutoCreatedNetworks(ctx context.Context) {
+	for _, name := range d.Networks {
+		if isNumericID(name) || isLabelSelector(name) {
+			continue
+		}
+
+		network, err := d.getClient().GetNetworkByName(ctx, name)
+		if err != nil {
+			logging.WarnStep("Could not get network %v: %v", name, err)
+			continue
+		}
+		if network == nil || network.Labels[config.LabelName(config.LabelAutoCreated)] != "true" {
+			continue
+		}
+		if len(network.Servers) != 0 {
+			log.Debugf("Network %v still in use by %d server(s), skipping cleanup", name, len(network.Servers))
+			continue
+		}
+
+		logging.Step("Destroying empty network %s", logging.Key(network.Name, network.ID))
+		// failure to remove a network is not a hard error
+		if err = d.getClient().DeleteNetwork(ctx, network); err != nil {
+			logging.WarnStep("Could not remove network: %v", err)
+		}
+	}
+}
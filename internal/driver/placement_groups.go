@@ -8,8 +8,8 @@ import (
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
-func (d *Driver) getAutoPlacementGroup() (*hcloud.PlacementGroup, error) {
-	res, err := d.getClient().GetPlacementGroupsByLabel(context.Background(), config.LabelName(config.LabelAutoSpreadPG))
+func (d *Driver) getAutoPlacementGroup(ctx context.Context) (*hcloud.PlacementGroup, error) {
+	res, err := d.getClient().GetPlacementGroupsByLabel(ctx, config.LabelName(config.LabelAutoSpreadPG))
 	if err != nil {
 		return nil, err
 	}
@@ -18,7 +18,7 @@ func (d *Driver) getAutoPlacementGroup() (*hcloud.PlacementGroup, error) {
 		return res[0], nil
 	}
 
-	grp, err := d.makePlacementGroup("Docker-Machine auto spread", map[string]string{
+	grp, err := d.makePlacementGroup(ctx, "Docker-Machine auto spread", map[string]string{
 		config.LabelName(config.LabelAutoSpreadPG): "true",
 		config.LabelName(config.LabelAutoCreated):  "true",
 	})
@@ -26,19 +26,22 @@ func (d *Driver) getAutoPlacementGroup() (*hcloud.PlacementGroup, error) {
 	return instrumented(grp), err
 }
 
-func (d *Driver) makePlacementGroup(name string, labels map[string]string) (*hcloud.PlacementGroup, error) {
-	grp, err := d.getClient().CreatePlacementGroup(context.Background(), instrumented(hcloud.PlacementGroupCreateOpts{
+func (d *Driver) makePlacementGroup(ctx context.Context, name string, labels map[string]string) (*hcloud.PlacementGroup, error) {
+	grp, err := d.getClient().CreatePlacementGroup(ctx, instrumented(hcloud.PlacementGroupCreateOpts{
 		Name:   name,
-		Labels: labels,
+		Labels: d.withOwnershipLabels(labels),
 		Type:   "spread",
 	}))
 
 	if grp != nil {
-		d.dangling = append(d.dangling, func() {
-			err := d.getClient().DeletePlacementGroup(context.Background(), grp)
+		d.journalRecord(journalKindPlacementGroup, grp.ID, grp.Name)
+		d.dangling = append(d.dangling, func(ctx context.Context) {
+			err := d.getClient().DeletePlacementGroup(ctx, grp)
 			if err != nil {
 				log.Errorf("Could not delete placement group: %v", err)
+				return
 			}
+			d.journalForget(journalKindPlacementGroup, grp.ID)
 		})
 	}
 
@@ -49,7 +52,7 @@ func (d *Driver) makePlacementGroup(name string, labels map[string]string) (*hcl
 	return instrumented(grp), nil
 }
 
-func (d *Driver) getPlacementGroup() (*hcloud.PlacementGroup, error) {
+func (d *Driver) getPlacementGroup(ctx context.Context) (*hcloud.PlacementGroup, error) {
 	if d.placementGroup == "" {
 		return nil, nil
 	} else if d.cachedPGrp != nil {
@@ -58,11 +61,11 @@ func (d *Driver) getPlacementGroup() (*hcloud.PlacementGroup, error) {
 
 	name := d.placementGroup
 	if name == config.AutoSpreadPGName {
-		grp, err := d.getAutoPlacementGroup()
+		grp, err := d.getAutoPlacementGroup(ctx)
 		d.cachedPGrp = grp
 		return grp, err
 	} else {
-		grp, err := d.getClient().GetPlacementGroup(context.Background(), name)
+		grp, err := d.getClient().GetPlacementGroup(ctx, name)
 		if err != nil {
 			return nil, err
 		}
@@ -71,6 +74,6 @@ func (d *Driver) getPlacementGroup() (*hcloud.PlacementGroup, error) {
 			return grp, nil
 		}
 
-		return d.makePlacementGroup(name, map[string]string{config.LabelName(config.LabelAutoCreated): "true"})
+		return d.makePlacementGroup(ctx, name, map[string]string{config.LabelName(config.LabelAutoCreated): "true"})
 	}
 }
@@ -0,0 +1,187 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/hetzner"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// namedPrimaryIPName returns the name of the machine's primary IP of the given type, e.g. `node-1-ipv4`
+func (d *Driver) namedPrimaryIPName(ipType hcloud.PrimaryIPType) string {
+	return fmt.Sprintf("%s-%s", d.GetMachineName(), ipType)
+}
+
+func (d *Driver) verifyNamedPrimaryIPFlags() error {
+	if !d.namedPrimaryIPs && (d.KeepPrimaryIPs || d.primaryIPAutoDelete) {
+		return d.flagFailure("--%v and --%v require --%v", flagKeepPrimaryIPs, flagPrimaryAutoDelete, flagNamedPrimaryIPs)
+	}
+	if d.KeepPrimaryIPs && d.primaryIPAutoDelete {
+		return d.flagFailure("--%v and --%v are mutually exclusive", flagKeepPrimaryIPs, flagPrimaryAutoDelete)
+	}
+	if d.namedPrimaryIPs && d.ExistingServer != "" {
+		return d.flagFailure("--%v and --%v are mutually exclusive", flagNamedPrimaryIPs, flagExistingServer)
+	}
+	return nil
+}
+
+// usesNamedPrimaryIP reports whether the primary IP of the given type is named after the machine
+func (d *Driver) usesNamedPrimaryIP(ipType hcloud.PrimaryIPType) bool {
+	if !d.namedPrimaryIPs {
+		return false
+	}
+	if ipType == hcloud.PrimaryIPTypeIPv4 {
+		return d.PrimaryIPv4 == "" && !d.DisablePublic4
+	}
+	return d.PrimaryIPv6 == "" && !d.DisablePublic6
+}
+
+// getNamedPrimaryIP returns the primary IP left behind by a previous machine of the same name, or nil if Hetzner is
+// to create a new one along with the server
+func (d *Driver) getNamedPrimaryIP(ctx context.Context, ipType hcloud.PrimaryIPType) (*hcloud.PrimaryIP, error) {
+	name := d.namedPrimaryIPName(ipType)
+	ip, err := d.getClient().GetPrimaryIPByName(ctx, name)
+	if err != nil || ip == nil {
+		return nil, err
+	}
+
+	if ip.Type != ipType {
+		return nil, fmt.Errorf("primary IP %v is of type %v, expected %v", name, ip.Type, ipType)
+	}
+	if ip.AssigneeID != 0 {
+		return nil, fmt.Errorf("primary IP %v is still assigned to server [ID: %d]", name, ip.AssigneeID)
+	}
+	return instrumented(ip), nil
+}
+
+// claimNamedPrimaryIPs names and labels the server's primary IPs after the machine and applies the requested
+// auto-delete setting. This happens only once creation has succeeded otherwise: until then, newly created primary IPs
+// keep Hetzner's auto-delete, so they are removed along with a rolled back server. Each claim is recorded before the
+// primary IP is changed, so it can be undone should a later claim fail.
+func (d *Driver) claimNamedPrimaryIPs(ctx context.Context, srv *hcloud.Server) error {
+	ids := []int64{srv.PublicNet.IPv4.ID, srv.PublicNet.IPv6.ID}
+	for i, ipType := range []hcloud.PrimaryIPType{hcloud.PrimaryIPTypeIPv4, hcloud.PrimaryIPTypeIPv6} {
+		id := ids[i]
+		if id == 0 || !d.usesNamedPrimaryIP(ipType) {
+			continue
+		}
+
+		ip, err := d.getClient().GetPrimaryIPByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if ip == nil {
+			return fmt.Errorf("primary IP [ID: %d] not found", id)
+		}
+
+		labels := maps.Clone(ip.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[config.LabelName(config.LabelAutoCreated)] = "true"
+		labels = d.withOwnershipLabels(labels)
+
+		name := d.namedPrimaryIPName(ipType)
+		d.makePrimaryIPDangling(ip, ip.Name == name)
+
+		if _, err = d.getClient().UpdatePrimaryIP(ctx, ip, hcloud.PrimaryIPUpdateOpts{
+			Name:       name,
+			Labels:     &labels,
+			AutoDelete: hcloud.Ptr(d.primaryIPAutoDelete),
+		}); err != nil {
+			return err
+		}
+
+		d.NamedPrimaryIPIDs = append(d.NamedPrimaryIPIDs, id)
+		logging.Substep("Claimed primary IP %s (%v)", logging.Key(name, id), ip.IP)
+	}
+	return nil
+}
+
+// makePrimaryIPDangling registers a primary IP about to be claimed for restoring should creation fail later on
+func (d *Driver) makePrimaryIPDangling(ip *hcloud.PrimaryIP, retained bool) {
+	kind := journalKindPrimaryIP
+	if retained {
+		kind = journalKindRetainedIP
+	}
+
+	d.journalRecord(kind, ip.ID, ip.Name)
+	d.dangling = append(d.dangling, func(ctx context.Context) {
+		if err := d.restorePrimaryIP(ctx, ip.ID, retained); err != nil {
+			log.Errorf("Could not restore primary IP: %v", err)
+			return
+		}
+		d.journalForget(kind, ip.ID)
+	})
+}
+
+// restorePrimaryIP undoes the claim of a primary IP by a failed creation: a primary IP retained by a previous machine
+// is released again, while a new one gets back auto-delete, so it is removed along with the server, or is deleted if
+// the server is gone already
+func (d *Driver) restorePrimaryIP(ctx context.Context, id int64, retained bool) error {
+	ip, err := d.getClient().GetPrimaryIPByID(ctx, id)
+	if err != nil || ip == nil {
+		return err
+	}
+
+	switch {
+	case retained:
+		logging.Substep("Releasing primary IP %s (%v)", logging.Key(ip.Name, ip.ID), ip.IP)
+		return d.releasePrimaryIP(ctx, ip)
+	case ip.AssigneeID == 0:
+		logging.Substep("Destroying primary IP %s (%v)", logging.Key(ip.Name, ip.ID), ip.IP)
+		return d.getClient().DeletePrimaryIP(ctx, ip)
+	default:
+		logging.Substep("Restoring auto-delete of primary IP %s (%v)", logging.Key(ip.Name, ip.ID), ip.IP)
+		_, err = d.getClient().UpdatePrimaryIP(ctx, ip, hcloud.PrimaryIPUpdateOpts{AutoDelete: hcloud.Ptr(true)})
+		return err
+	}
+}
+
+// releasePrimaryIP drops the instance label of a retained primary IP, so the next machine of the same name can claim it
+func (d *Driver) releasePrimaryIP(ctx context.Context, ip *hcloud.PrimaryIP) error {
+	labels := maps.Clone(ip.Labels)
+	delete(labels, config.LabelName(config.LabelInstanceID))
+
+	_, err := d.getClient().UpdatePrimaryIP(ctx, ip, hcloud.PrimaryIPUpdateOpts{
+		Labels:     &labels,
+		AutoDelete: hcloud.Ptr(false),
+	})
+	return err
+}
+
+// removeNamedPrimaryIPs deletes or, if requested, retains the machine's named primary IPs once the server is gone
+func (d *Driver) removeNamedPrimaryIPs(ctx context.Context) {
+	for _, id := range d.NamedPrimaryIPIDs {
+		ip, err := d.getClient().GetPrimaryIPByID(ctx, id)
+		if err != nil {
+			logging.WarnStep("Could not get primary IP [ID: %d]: %v", id, err)
+			continue
+		}
+		if ip == nil {
+			// deleted along with the server due to auto-delete
+			continue
+		}
+
+		if d.KeepPrimaryIPs {
+			logging.Step("Keeping primary IP %s (%v)", logging.Key(ip.Name, ip.ID), ip.IP)
+			err = d.releasePrimaryIP(ctx, ip)
+		} else {
+			logging.Step("Destroying primary IP %s (%v)", logging.Key(ip.Name, ip.ID), ip.IP)
+			err = d.getClient().DeletePrimaryIP(ctx, ip)
+			if hetzner.IsNotFoundError(err) {
+				err = nil
+			}
+		}
+
+		// failure to clean up a primary IP is not a hard error
+		if err != nil {
+			logging.WarnStep("Could not clean up primary IP [ID: %d]: %v", id, err)
+		}
+	}
+}
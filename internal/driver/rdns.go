@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+type rdnsData struct {
+	MachineName string
+}
+
+func (d *Driver) setRDNSFromFlags(raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	tmpl, err := parseTemplate("reverse DNS", raw)
+	if err != nil {
+		return d.flagFailure("--%v: %v", flagRDNS, err)
+	}
+	if d.RDNS, err = renderTemplate(tmpl, rdnsData{MachineName: d.GetMachineName()}); err != nil {
+		return d.flagFailure("--%v: %v", flagRDNS, err)
+	}
+	if d.ExistingServer != "" {
+		return d.flagFailure("--%v and --%v are mutually exclusive", flagRDNS, flagExistingServer)
+	}
+	return nil
+}
+
+// rdnsAddresses returns the public addresses of the server that receive a reverse DNS entry: the IPv4 address and
+// the machine's address within the IPv6 network
+func (d *Driver) rdnsAddresses(srv *hcloud.Server) []string {
+	var ips []string
+	if !srv.PublicNet.IPv4.IsUnspecified() {
+		ips = append(ips, srv.PublicNet.IPv4.IP.String())
+	}
+	if srv.PublicNet.IPv6.Network != nil {
+		ips = append(ips, d.publicIPv6Address(srv.PublicNet.IPv6).String())
+	}
+	return ips
+}
+
+// setRDNS points the reverse DNS entries of the server's public addresses to the configured hostname
+func (d *Driver) setRDNS(ctx context.Context, srv *hcloud.Server) error {
+	if d.RDNS == "" {
+		return nil
+	}
+
+	for _, ip := range d.rdnsAddresses(srv) {
+		logging.Step("Setting reverse DNS of %v to %v", ip, d.RDNS)
+		action, err := d.getClient().ChangeServerDNSPtr(ctx, srv, ip, hcloud.Ptr(d.RDNS))
+		if err != nil {
+			return err
+		}
+		if err = d.waitForAction(ctx, action); err != nil {
+			return fmt.Errorf("could not wait for reverse DNS change: %w", err)
+		}
+		d.RDNSAddresses = append(d.RDNSAddresses, ip)
+	}
+	return nil
+}
+
+// resetRDNS restores the default reverse DNS entries, which matters for primary IPs outliving the server
+func (d *Driver) resetRDNS(ctx context.Context) {
+	if len(d.RDNSAddresses) == 0 {
+		return
+	}
+
+	srv, err := d.getServerHandleNullable(ctx)
+	if err != nil || srv == nil {
+		return
+	}
+
+	for _, ip := range d.RDNSAddresses {
+		logging.Step("Resetting reverse DNS of %v", ip)
+		action, err := d.getClient().ChangeServerDNSPtr(ctx, srv, ip, nil)
+		if err == nil {
+			err = d.waitForAction(ctx, action)
+		}
+
+		// failure to reset reverse DNS is not a hard error
+		if err != nil {
+			logging.WarnStep("Could not reset reverse DNS of %v: %v", ip, err)
+		}
+	}
+	d.RDNSAddresses = nil
+}
@@ -0,0 +1,262 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// reconcileChange is a difference between the live server and the machine's configuration, along with its remedy
+type reconcileChange struct {
+	description string
+	apply       func(ctx context.Context) error
+}
+
+// Reconcile compares the machine's server with its stored configuration and returns the differences found; with
+// apply, the server is brought in line with the configuration. It backs the reconcile subcommand.
+func (d *Driver) Reconcile(apply bool) ([]string, error) {
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	srv, err := d.getServerHandle(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get server handle: %w", err)
+	}
+
+	changes, err := d.planReconcile(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+
+	var descriptions []string
+	for _, change := range changes {
+		descriptions = append(descriptions, change.description)
+	}
+	if apply {
+		err = d.applyReconcile(ctx, changes)
+	}
+	return descriptions, err
+}
+
+// reconcile brings the server in line with the stored configuration, see planReconcile
+func (d *Driver) reconcile(ctx context.Context, srv *hcloud.Server) error {
+	changes, err := d.planReconcile(ctx, srv)
+	if err != nil {
+		return err
+	}
+	return d.applyReconcile(ctx, changes)
+}
+
+func (d *Driver) applyReconcile(ctx context.Context, changes []reconcileChange) error {
+	for _, change := range changes {
+		logging.Step("Reconciling: %v", change.description)
+		if err := change.apply(ctx); err != nil {
+			return fmt.Errorf("could not %v: %w", change.description, err)
+		}
+	}
+	if len(changes) != 0 {
+		// attachments and labels changed
+		d.cachedServer = nil
+	}
+	return nil
+}
+
+// planReconcile compares the firewalls, networks and labels of the live server, as well as its Docker firewall, with the
+// stored configuration. Servers adopted through --hetzner-existing-server only receive missing firewalls, networks and
+// labels, as their original configuration is not known.
+func (d *Driver) planReconcile(ctx context.Context, srv *hcloud.Server) ([]reconcileChange, error) {
+	docker, err := d.planDockerFirewallChanges(ctx, srv)
+	if err != nil {
+		return nil, fmt.Errorf("could not compare Docker firewall: %w", err)
+	}
+	firewalls, err := d.planFirewallChanges(ctx, srv)
+	if err != nil {
+		return nil, fmt.Errorf("could not compare firewalls: %w", err)
+	}
+	networks, err := d.planNetworkChanges(ctx, srv)
+	if err != nil {
+		return nil, fmt.Errorf("could not compare networks: %w", err)
+	}
+
+	changes := append(append(docker, firewalls...), networks...)
+	if labels := desiredServerLabels(srv.Labels, d.ServerLabels, d.IsExistingServer); !maps.Equal(labels, srv.Labels) {
+		changes = append(changes, reconcileChange{
+			description: fmt.Sprintf("update labels of %s", logging.Server(srv.Name, srv.ID)),
+			apply: func(ctx context.Context) error {
+				_, err := d.getClient().UpdateServer(ctx, srv, hcloud.ServerUpdateOpts{Labels: labels})
+				return err
+			},
+		})
+	}
+	return changes, nil
+}
+
+// desiredServerLabels returns the labels the server should carry: the configured labels and the driver's own labels,
+// plus any other labels on adopted servers
+func desiredServerLabels(live, configured map[string]string, adopted bool) map[string]string {
+	desired := make(map[string]string, len(live)+len(configured))
+	for name, value := range live {
+		if adopted || config.IsDriverLabel(name) {
+			desired[name] = value
+		}
+	}
+	maps.Copy(desired, configured)
+	return desired
+}
+
+// desiredFirewalls returns the firewalls configured through --hetzner-firewalls, and the one created from the rules
+// file; the Docker firewall is handled by planDockerFirewallChanges
+func (d *Driver) desiredFirewalls(ctx context.Context) ([]*hcloud.Firewall, error) {
+	firewalls, err := d.resolveFirewalls(ctx, d.Firewalls)
+	if err != nil {
+		return nil, err
+	}
+	if d.RulesFirewallID == 0 {
+		return firewalls, nil
+	}
+
+	firewall, err := d.getClient().GetFirewallByID(ctx, d.RulesFirewallID)
+	if err != nil {
+		return nil, err
+	}
+	if firewall == nil {
+		logging.WarnStep("Firewall [ID: %d] no longer exists", d.RulesFirewallID)
+		return firewalls, nil
+	}
+	return append(firewalls, firewall), nil
+}
+
+func (d *Driver) planFirewallChanges(ctx context.Context, srv *hcloud.Server) ([]reconcileChange, error) {
+	desired, err := d.desiredFirewalls(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]bool)
+	for _, status := range srv.PublicNet.Firewalls {
+		applied[status.Firewall.ID] = true
+	}
+
+	var changes []reconcileChange
+	wanted := map[int64]bool{d.DockerFirewallID: d.DockerFirewall}
+	for _, firewall := range desired {
+		wanted[firewall.ID] = true
+		if applied[firewall.ID] {
+			continue
+		}
+		changes = append(changes, reconcileChange{
+			description: fmt.Sprintf("apply firewall %s", logging.Key(firewall.Name, firewall.ID)),
+			apply: func(ctx context.Context) error {
+				return d.applyFirewall(ctx, firewall, srv)
+			},
+		})
+	}
+	if d.IsExistingServer {
+		return changes, nil
+	}
+
+	for _, status := range srv.PublicNet.Firewalls {
+		if wanted[status.Firewall.ID] {
+			continue
+		}
+		firewall, err := d.getClient().GetFirewallByID(ctx, status.Firewall.ID)
+		if err != nil {
+			return nil, err
+		}
+		if firewall == nil || !firewallAppliedToServer(firewall, srv) {
+			// firewalls applied through a label selector can only be removed by changing the server's labels
+			continue
+		}
+		changes = append(changes, reconcileChange{
+			description: fmt.Sprintf("remove firewall %s", logging.Key(firewall.Name, firewall.ID)),
+			apply: func(ctx context.Context) error {
+				actions, err := d.getClient().RemoveFirewallFromServer(ctx, firewall, srv)
+				if err != nil {
+					return err
+				}
+				return d.waitForMultipleActions(ctx, "firewall.RemoveResources", actions)
+			},
+		})
+	}
+	return changes, nil
+}
+
+func (d *Driver) applyFirewall(ctx context.Context, firewall *hcloud.Firewall, srv *hcloud.Server) error {
+	actions, err := d.getClient().ApplyFirewallToServer(ctx, firewall, srv)
+	if err != nil {
+		return err
+	}
+	return d.waitForMultipleActions(ctx, "firewall.ApplyResources", actions)
+}
+
+func (d *Driver) planNetworkChanges(ctx context.Context, srv *hcloud.Server) ([]reconcileChange, error) {
+	desired, err := d.resolveNetworks(ctx, d.Networks)
+	if err != nil {
+		return nil, err
+	}
+
+	static := make(map[int64]*staticNetworkAddress)
+	for name, addr := range d.StaticNetworks {
+		network, err := d.getNetworkCached(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		static[network.ID] = addr
+	}
+
+	var changes []reconcileChange
+	wanted := make(map[int64]bool)
+	for _, network := range desired {
+		wanted[network.ID] = true
+		if selectPrivateNet(srv, network) != nil {
+			continue
+		}
+
+		opts := hcloud.ServerAttachToNetworkOpts{Network: network}
+		if addr := static[network.ID]; addr != nil {
+			opts.IP = addr.IP
+			opts.AliasIPs = addr.AliasIPs
+		}
+		changes = append(changes, reconcileChange{
+			description: fmt.Sprintf("attach to network %s", logging.Key(network.Name, network.ID)),
+			apply: func(ctx context.Context) error {
+				action, err := d.getClient().AttachServerToNetwork(ctx, srv, opts)
+				if err != nil {
+					return err
+				}
+				return d.waitForAction(ctx, action)
+			},
+		})
+	}
+	if d.IsExistingServer {
+		return changes, nil
+	}
+
+	for _, privateNet := range srv.PrivateNet {
+		if privateNet.Network == nil || wanted[privateNet.Network.ID] {
+			continue
+		}
+		network, err := d.getClient().GetNetworkByID(ctx, privateNet.Network.ID)
+		if err != nil {
+			return nil, err
+		}
+		if network == nil {
+			continue
+		}
+		changes = append(changes, reconcileChange{
+			description: fmt.Sprintf("detach from network %s", logging.Key(network.Name, network.ID)),
+			apply: func(ctx context.Context) error {
+				action, err := d.getClient().DetachServerFromNetwork(ctx, srv, network)
+				if err != nil {
+					return err
+				}
+				return d.waitForAction(ctx, action)
+			},
+		})
+	}
+	return changes, nil
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// lookupServer fetches the machine's server, rediscovering it when the stored ID is missing or stale;
+// returns nil if no server could be found
+func (d *Driver) lookupServer(ctx context.Context) (*hcloud.Server, error) {
+	if d.ServerID != 0 {
+		srv, err := d.getClient().GetServerByID(ctx, d.ServerID)
+		if err != nil || srv != nil {
+			return srv, err
+		}
+	}
+
+	srv, err := d.rediscoverServer(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not rediscover server: %w", err)
+	}
+	if srv == nil {
+		return nil, nil
+	}
+
+	if d.ServerID == 0 {
+		logging.WarnStep("Server ID was lost, recovered %s", logging.Server(srv.Name, srv.ID))
+	} else {
+		logging.WarnStep("Server [ID: %d] no longer exists, recovered %s", d.ServerID, logging.Server(srv.Name, srv.ID))
+	}
+	d.ServerID = srv.ID
+	return srv, nil
+}
+
+// rediscoverServer searches for the machine's server by its ownership labels, falling back to its name. Without
+// a stored ID the machine may never have had a server, e.g. due to a name conflict, so the name alone is not
+// trusted then; even with one, a server found by name must be recognisable as the machine's, as it would otherwise
+// be deleted on removal.
+func (d *Driver) rediscoverServer(ctx context.Context) (*hcloud.Server, error) {
+	if d.InstanceID != "" {
+		servers, err := d.getClient().GetServersByLabel(ctx, config.InstanceSelector(d.InstanceID))
+		if err != nil {
+			return nil, err
+		}
+		switch len(servers) {
+		case 0:
+		case 1:
+			return servers[0], nil
+		default:
+			return nil, fmt.Errorf("found %d servers owned by instance %v", len(servers), d.InstanceID)
+		}
+	}
+
+	if d.ServerID == 0 {
+		return nil, nil
+	}
+
+	srv, err := d.getClient().GetServerByName(ctx, d.GetMachineName())
+	if err != nil || srv == nil {
+		return nil, err
+	}
+	if !d.isMachineServer(srv) {
+		logging.WarnStep("Ignoring %s: it shares the machine's name only", logging.Server(srv.Name, srv.ID))
+		return nil, nil
+	}
+	return srv, nil
+}
+
+// mayOwnServer rejects servers created for another machine of the same name; servers without ownership labels
+// predate them and are accepted
+func (d *Driver) mayOwnServer(srv *hcloud.Server) bool {
+	instanceID, labelled := srv.Labels[config.LabelName(config.LabelInstanceID)]
+	return !labelled || instanceID == d.InstanceID
+}
+
+// isMachineServer reports whether a server found by name is the machine's: it must carry the machine's instance ID,
+// or lack ownership labels and have been created with the machine's server type and image
+func (d *Driver) isMachineServer(srv *hcloud.Server) bool {
+	if instanceID, labelled := srv.Labels[config.LabelName(config.LabelInstanceID)]; labelled {
+		return d.InstanceID != "" && instanceID == d.InstanceID
+	}
+
+	if srv.ServerType == nil || srv.ServerType.Name != d.Type || srv.Image == nil {
+		return false
+	}
+	if d.ImageID != 0 {
+		return srv.Image.ID == d.ImageID
+	}
+	return srv.Image.Name == d.Image
+}
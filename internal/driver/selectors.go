@@ -0,0 +1,189 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// labelSelectorPrefix marks a resource reference as label selector, e.g. `label:role=ingress`
+const labelSelectorPrefix = "label:"
+
+func isLabelSelector(ref string) bool {
+	return strings.HasPrefix(ref, labelSelectorPrefix)
+}
+
+// resolveReferences resolves resource references in order, each being an ID or name, or a label selector expanding to
+// all matching resources; resources referenced more than once are only returned once
+func resolveReferences[T any](refs []string, kind string, get func(nameOrID string) (T, error),
+	list func(selector string) ([]T, error), id func(T) int64) ([]T, error) {
+	var resolved []T
+	seen := make(map[int64]bool)
+	add := func(res T) {
+		if !seen[id(res)] {
+			seen[id(res)] = true
+			resolved = append(resolved, res)
+		}
+	}
+
+	for _, ref := range refs {
+		selector, isSelector := strings.CutPrefix(ref, labelSelectorPrefix)
+		if !isSelector {
+			res, err := get(ref)
+			if err != nil {
+				return nil, err
+			}
+			add(res)
+			continue
+		}
+
+		matches, err := list(selector)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no %v matches label selector %v", kind, selector)
+		}
+		for _, res := range matches {
+			add(res)
+		}
+	}
+	return resolved, nil
+}
+
+func (d *Driver) resolveFirewalls(ctx context.Context, refs []string) ([]*hcloud.Firewall, error) {
+	return resolveReferences(refs, "firewall",
+		func(nameOrID string) (*hcloud.Firewall, error) { return d.getClient().GetFirewall(ctx, nameOrID) },
+		func(selector string) ([]*hcloud.Firewall, error) {
+			return d.getClient().GetFirewallsByLabel(ctx, selector)
+		},
+		func(firewall *hcloud.Firewall) int64 { return firewall.ID })
+}
+
+func (d *Driver) resolveNetworks(ctx context.Context, refs []string) ([]*hcloud.Network, error) {
+	return resolveReferences(refs, "network",
+		func(nameOrID string) (*hcloud.Network, error) { return d.getNetworkCached(ctx, nameOrID) },
+		func(selector string) ([]*hcloud.Network, error) {
+			return d.getClient().GetNetworksByLabel(ctx, selector)
+		},
+		func(network *hcloud.Network) int64 { return network.ID })
+}
+
+func (d *Driver) resolveVolumes(ctx context.Context, refs []string) ([]*hcloud.Volume, error) {
+	return resolveReferences(refs, "volume",
+		func(nameOrID string) (*hcloud.Volume, error) { return d.getClient().GetVolume(ctx, nameOrID) },
+		func(selector string) ([]*hcloud.Volume, error) { return d.getClient().GetVolumesByLabel(ctx, selector) },
+		func(volume *hcloud.Volume) int64 { return volume.ID })
+}
+
+// networkInZone reports whether servers in the given network zone can be attached to the network
+func networkInZone(network *hcloud.Network, zone hcloud.NetworkZone) bool {
+	return slices.ContainsFunc(network.Subnets, func(subnet hcloud.NetworkSubnet) bool {
+		return subnet.NetworkZone == zone
+	})
+}
+
+// filterPlacementCandidates narrows the candidate locations down to those the given volumes and networks can be used
+// from: volumes must all reside in one location, and every network needs a subnet in the location's network zone. A
+// single nil candidate, i.e. any location, is replaced by the location of the volumes, if any.
+func filterPlacementCandidates(candidates []*hcloud.Location, volumes []*hcloud.Volume, networks []*hcloud.Network) ([]*hcloud.Location, error) {
+	var volumeLocation *hcloud.Location
+	for _, volume := range volumes {
+		if volume.Location == nil {
+			continue
+		}
+		if volumeLocation != nil && volume.Location.Name != volumeLocation.Name {
+			return nil, fmt.Errorf("volume %v is in %v, but other volumes are in %v", volume.Name, volume.Location.Name, volumeLocation.Name)
+		}
+		volumeLocation = volume.Location
+	}
+
+	if volumeLocation != nil {
+		if len(candidates) == 1 && candidates[0] == nil {
+			candidates = []*hcloud.Location{volumeLocation}
+		} else {
+			candidates = slices.DeleteFunc(slices.Clone(candidates), func(location *hcloud.Location) bool {
+				return location.Name != volumeLocation.Name
+			})
+			if len(candidates) == 0 {
+				return nil, fmt.Errorf("volumes are in %v, which is not a candidate location", volumeLocation.Name)
+			}
+		}
+	}
+
+	if len(candidates) == 1 && candidates[0] == nil {
+		// Hetzner chooses a location suitable for the networks
+		return candidates, nil
+	}
+
+	for _, network := range networks {
+		candidates = slices.DeleteFunc(slices.Clone(candidates), func(location *hcloud.Location) bool {
+			return !networkInZone(network, location.NetworkZone)
+		})
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("network %v has no subnet in the network zone of any remaining candidate location", network.Name)
+		}
+	}
+	return candidates, nil
+}
+
+// verifyVolumesDetached rejects volumes attached to another server, which label selectors in particular may match
+func verifyVolumesDetached(volumes []*hcloud.Volume) error {
+	for _, volume := range volumes {
+		if volume.Server != nil {
+			return fmt.Errorf("volume %s is attached to server [ID: %d]", logging.Key(volume.Name, volume.ID), volume.Server.ID)
+		}
+	}
+	return nil
+}
+
+// verifyResourcePlacement ensures that the attached volumes and networks, including those found by label selectors, can
+// be used together from at least one candidate location, and that the volumes are not in use elsewhere
+func (d *Driver) verifyResourcePlacement(ctx context.Context) error {
+	if len(d.Volumes) == 0 && len(d.Networks) == 0 {
+		return nil
+	}
+
+	volumes, err := d.resolveVolumes(ctx, d.Volumes)
+	if err != nil {
+		return err
+	}
+	if err = verifyVolumesDetached(volumes); err != nil {
+		return err
+	}
+	networks, err := d.resolveNetworks(ctx, d.Networks)
+	if err != nil {
+		return err
+	}
+	candidates, err := d.getLocationCandidates(ctx)
+	if err != nil {
+		return err
+	}
+
+	configured := candidates
+	if len(d.StaticNetworks) != 0 && len(candidates) == 1 && candidates[0] == nil {
+		// networks with fixed addresses are attached after creation, so Hetzner cannot take them into account when it
+		// chooses the location; consider every location instead, which the networks' zones then narrow down
+		if candidates, err = d.getClient().GetLocations(ctx); err != nil {
+			return err
+		}
+	}
+
+	usable, err := filterPlacementCandidates(candidates, volumes, networks)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(usable, configured) {
+		var names []string
+		for _, location := range usable {
+			names = append(names, locationName(location))
+		}
+		logging.Substep("Attached volumes and networks restrict the server to %v", strings.Join(names, ", "))
+		d.cachedLocations = usable
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/hetzner"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+var errArchitectureConflict = errors.New("image architecture does not match server type")
+
+// getTypeCandidates resolves the configured, comma-separated server types in order
+func (d *Driver) getTypeCandidates(ctx context.Context) ([]*hcloud.ServerType, error) {
+	if d.cachedTypes != nil {
+		return d.cachedTypes, nil
+	}
+
+	names := splitList(d.Type)
+	if len(names) == 0 {
+		names = []string{defaultType}
+	}
+
+	candidates := make([]*hcloud.ServerType, 0, len(names))
+	for _, name := range names {
+		stype, err := d.getClient().GetServerType(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, stype)
+	}
+
+	d.cachedTypes = candidates
+	return instrumented(candidates), nil
+}
+
+// isTypeDeprecatedIn reports whether stype is deprecated in all of the given locations; a nil location
+// stands for any location the type is offered in
+func isTypeDeprecatedIn(stype *hcloud.ServerType, locations []*hcloud.Location) bool {
+	if len(stype.Locations) == 0 {
+		return false
+	}
+
+	for _, location := range locations {
+		for _, offered := range stype.Locations {
+			if offered.Location == nil || (location != nil && offered.Location.Name != location.Name) {
+				continue
+			}
+			if !offered.IsDeprecated() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// verifyImageArchitecture rejects server types the resolved image cannot run on
+func verifyImageArchitecture(image *hcloud.Image, stype *hcloud.ServerType) error {
+	if image.Architecture != "" && image.Architecture != stype.Architecture {
+		return fmt.Errorf("%w: image %v is %v, %v is %v",
+			errArchitectureConflict, image.Name, image.Architecture, stype.Name, stype.Architecture)
+	}
+	return nil
+}
+
+// isTypeUnusableError reports whether server creation failed in a way that a different server type may avoid
+func isTypeUnusableError(err error) bool {
+	return hetzner.IsUnavailableError(err) || hetzner.IsInvalidServerTypeError(err)
+}
+
+// createServerWithFallback attempts server creation with each candidate server type in order, re-resolving
+// the image for each of them, and moves on when a type is deprecated, unavailable or cannot run the image
+func (d *Driver) createServerWithFallback(ctx context.Context) (hcloud.ServerCreateResult, error) {
+	types, err := d.getTypeCandidates(ctx)
+	if err != nil {
+		return hcloud.ServerCreateResult{}, fmt.Errorf("could not get type: %w", err)
+	}
+	locations, err := d.getLocationCandidates(ctx)
+	if err != nil {
+		return hcloud.ServerCreateResult{}, fmt.Errorf("could not get location: %w", err)
+	}
+
+	var lastErr error
+	for i, stype := range types {
+		last := i == len(types)-1
+
+		d.cachedType = stype
+		d.cachedImage = nil
+
+		if !last && isTypeDeprecatedIn(stype, locations) {
+			logging.WarnStep("Skipping server type %s: deprecated", stype.Name)
+			lastErr = fmt.Errorf("server type %v is deprecated", stype.Name)
+			continue
+		}
+
+		image, err := d.getImage(ctx)
+		if err == nil {
+			err = verifyImageArchitecture(image, stype)
+		} else {
+			err = fmt.Errorf("could not get image: %w", err)
+		}
+		if err != nil {
+			if last {
+				return hcloud.ServerCreateResult{}, err
+			}
+			logging.WarnStep("Skipping server type %s: %v", stype.Name, err)
+			lastErr = err
+			continue
+		}
+
+		srv, err := d.createServerInCandidateLocations(ctx)
+		if err == nil {
+			d.Type = stype.Name
+			return srv, nil
+		}
+
+		if last || !isTypeUnusableError(err) {
+			return hcloud.ServerCreateResult{}, err
+		}
+
+		logging.WarnStep("Server type %s is unavailable, trying next candidate: %v", stype.Name, err)
+		lastErr = err
+	}
+
+	return hcloud.ServerCreateResult{}, fmt.Errorf("no usable server type candidate: %w", lastErr)
+}
@@ -5,47 +5,45 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/docker/machine/libmachine/state"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"go.yaml.in/yaml/v2"
 )
 
-func (d *Driver) waitForRunningServer() error {
-	start_time := time.Now()
+func (d *Driver) waitForRunningServer(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, d.WaitForRunningTimeout, errWaitForRunningTimeout)
+	defer cancel()
+
 	for {
-		srvstate, err := d.GetState()
+		srvstate, err := d.getState(ctx)
 		if err != nil {
-			return fmt.Errorf("could not get state: %w", err)
+			return fmt.Errorf("could not get state: %w", contextError(ctx, err))
 		}
 
 		if srvstate == state.Running {
 			break
 		}
 
-		elapsed_time := time.Since(start_time).Seconds()
-		if d.WaitForRunningTimeout > 0 && int(elapsed_time) > d.WaitForRunningTimeout {
-			return fmt.Errorf("server exceeded wait-for-running-timeout")
+		if err = d.waitPolling(ctx); err != nil {
+			return err
 		}
-
-		time.Sleep(time.Duration(d.WaitOnPolling) * time.Second)
 	}
 	return nil
 }
 
-func (d *Driver) waitForInitialStartup(srv hcloud.ServerCreateResult) error {
+func (d *Driver) waitForInitialStartup(ctx context.Context, srv hcloud.ServerCreateResult) error {
 	if len(srv.NextActions) != 0 {
-		if err := d.waitForMultipleActions("server.NextActions", srv.NextActions); err != nil {
+		if err := d.waitForMultipleActions(ctx, "server.NextActions", srv.NextActions); err != nil {
 			return fmt.Errorf("could not wait for NextActions: %w", err)
 		}
 	}
 
-	return d.waitForRunningServer()
+	return d.waitForRunningServer(ctx)
 }
 
-func (d *Driver) makeCreateServerOptions() (*hcloud.ServerCreateOpts, error) {
-	pgrp, err := d.getPlacementGroup()
+func (d *Driver) makeCreateServerOptions(ctx context.Context) (*hcloud.ServerCreateOpts, error) {
+	pgrp, err := d.getPlacementGroup(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -58,43 +56,58 @@ func (d *Driver) makeCreateServerOptions() (*hcloud.ServerCreateOpts, error) {
 	srvopts := hcloud.ServerCreateOpts{
 		Name:           d.GetMachineName(),
 		UserData:       userData,
-		Labels:         d.ServerLabels,
+		Labels:         d.withOwnershipLabels(d.ServerLabels),
 		PlacementGroup: pgrp,
 	}
 
-	err = d.setPublicNetIfRequired(&srvopts)
+	err = d.setPublicNetIfRequired(ctx, &srvopts)
 	if err != nil {
 		return nil, err
 	}
 
-	networks, err := d.createNetworks()
+	networks, err := d.createNetworks(ctx)
 	if err != nil {
 		return nil, err
 	}
 	srvopts.Networks = networks
 
-	firewalls, err := d.createFirewalls()
+	firewalls, err := d.createFirewalls(ctx)
 	if err != nil {
 		return nil, err
 	}
 	srvopts.Firewalls = firewalls
 
-	volumes, err := d.createVolumes()
+	volumes, err := d.createVolumes(ctx)
 	if err != nil {
 		return nil, err
 	}
 	srvopts.Volumes = volumes
 
-	if srvopts.Location, err = d.getLocationNullable(); err != nil {
+	if err = d.setVolumeMounts(&srvopts); err != nil {
+		return nil, err
+	}
+
+	if err = d.setIPv6Host(&srvopts); err != nil {
+		return nil, err
+	}
+
+	if err = d.setFloatingIPConfig(ctx, &srvopts); err != nil {
+		return nil, err
+	}
+
+	if srvopts.Location, err = d.getLocationNullable(ctx); err != nil {
 		return nil, fmt.Errorf("could not get location: %w", err)
 	}
-	if srvopts.ServerType, err = d.getType(); err != nil {
+	if srvopts.ServerType, err = d.getType(ctx); err != nil {
 		return nil, fmt.Errorf("could not get type: %w", err)
 	}
-	if srvopts.Image, err = d.getImage(); err != nil {
+	if srvopts.Image, err = d.getImage(ctx); err != nil {
 		return nil, fmt.Errorf("could not get image: %w", err)
 	}
-	key, err := d.getKey()
+	if err = verifyLocationBoundResources(&srvopts); err != nil {
+		return nil, err
+	}
+	key, err := d.getKey(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not get ssh key: %w", err)
 	}
@@ -130,6 +143,57 @@ func (d *Driver) getUserData() (string, error) {
 	return merged, nil
 }
 
+// cloudConfigHeader starts user data in cloud-config format, the only one the driver can merge its own configuration into
+const cloudConfigHeader = "#cloud-config"
+
+// verifyCloudConfigUserData rejects user data in another format, e.g. a shell script, if the driver is going to add
+// cloud-config to it; merging would otherwise only fail right before the server is created
+func (d *Driver) verifyCloudConfigUserData() error {
+	var features []string
+	if d.configureFloatingIPs && len(d.floatingIPs) != 0 {
+		features = append(features, "--"+flagFloatingIPConfig)
+	}
+	if d.usesCloudInitMounts() && len(d.Volumes) != 0 {
+		features = append(features, fmt.Sprintf("--%v/--%v", flagVolumeMountPoint, flagVolumeFormat))
+	}
+	if d.usesCustomIPv6Host() {
+		features = append(features, "--"+flagIPv6Host)
+	}
+	if len(features) == 0 {
+		return nil
+	}
+
+	userData, err := d.getUserData()
+	if err != nil {
+		return err
+	}
+	if userData != "" && !strings.HasPrefix(userData, cloudConfigHeader) {
+		return fmt.Errorf("%v require user data in cloud-config format, starting with %q", strings.Join(features, ", "), cloudConfigHeader)
+	}
+	return nil
+}
+
+// addCloudConfig merges cloud-config generated by the driver into the server's user data
+func addCloudConfig(srvopts *hcloud.ServerCreateOpts, cloudConfig map[string]interface{}) error {
+	buf, err := yaml.Marshal(cloudConfig)
+	if err != nil {
+		return fmt.Errorf("could not serialize cloud-config: %w", err)
+	}
+	userData := "#cloud-config\n" + string(buf)
+
+	if srvopts.UserData == "" {
+		srvopts.UserData = userData
+		return nil
+	}
+
+	merged, err := mergeUserData(srvopts.UserData, userData)
+	if err != nil {
+		return err
+	}
+	srvopts.UserData = merged
+	return nil
+}
+
 func mergeUserData(base, additional string) (string, error) {
 	var baseMap, additionalMap map[string]interface{}
 
@@ -184,38 +248,61 @@ func mergeYAMLMaps(base, additional map[string]interface{}) {
 	}
 }
 
-func (d *Driver) createNetworks() ([]*hcloud.Network, error) {
-	networks := []*hcloud.Network{}
-	for _, networkIDorName := range d.Networks {
-		network, err := d.getClient().GetNetwork(context.Background(), networkIDorName)
+func (d *Driver) createNetworks(ctx context.Context) ([]*hcloud.Network, error) {
+	all, err := d.resolveNetworks(ctx, d.Networks)
+	if err != nil {
+		return nil, err
+	}
+
+	// networks with fixed addresses are attached after creation, see attachStaticNetworks
+	static := make(map[int64]bool)
+	for name := range d.StaticNetworks {
+		network, err := d.getNetworkCached(ctx, name)
 		if err != nil {
 			return nil, err
 		}
-		networks = append(networks, network)
+		static[network.ID] = true
+	}
+
+	networks := []*hcloud.Network{}
+	for _, network := range all {
+		if !static[network.ID] {
+			networks = append(networks, network)
+		}
 	}
 	return instrumented(networks), nil
 }
 
-func (d *Driver) createFirewalls() ([]*hcloud.ServerCreateFirewall, error) {
+func (d *Driver) createFirewalls(ctx context.Context) ([]*hcloud.ServerCreateFirewall, error) {
+	resolved, err := d.resolveFirewalls(ctx, d.Firewalls)
+	if err != nil {
+		return nil, err
+	}
+
 	firewalls := []*hcloud.ServerCreateFirewall{}
-	for _, firewallIDorName := range d.Firewalls {
-		firewall, err := d.getClient().GetFirewall(context.Background(), firewallIDorName)
+	for _, firewall := range resolved {
+		firewalls = append(firewalls, &hcloud.ServerCreateFirewall{Firewall: *firewall})
+	}
+
+	for _, get := range []func(context.Context) (*hcloud.Firewall, error){d.getRulesFirewall, d.getDockerFirewall} {
+		firewall, err := get(ctx)
 		if err != nil {
 			return nil, err
 		}
-		firewalls = append(firewalls, &hcloud.ServerCreateFirewall{Firewall: *firewall})
+		if firewall != nil {
+			firewalls = append(firewalls, &hcloud.ServerCreateFirewall{Firewall: *firewall})
+		}
 	}
 	return instrumented(firewalls), nil
 }
 
-func (d *Driver) createVolumes() ([]*hcloud.Volume, error) {
-	volumes := []*hcloud.Volume{}
-	for _, volumeIDorName := range d.Volumes {
-		volume, err := d.getClient().GetVolume(context.Background(), volumeIDorName)
-		if err != nil {
-			return nil, err
-		}
-		volumes = append(volumes, volume)
+func (d *Driver) createVolumes(ctx context.Context) ([]*hcloud.Volume, error) {
+	volumes, err := d.resolveVolumes(ctx, d.Volumes)
+	if err != nil {
+		return nil, err
+	}
+	if volumes == nil {
+		volumes = []*hcloud.Volume{}
 	}
 	return instrumented(volumes), nil
 }
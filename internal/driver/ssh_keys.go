@@ -13,13 +13,13 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
-func (d *Driver) setupExistingKey() error {
+func (d *Driver) setupExistingKey(ctx context.Context) error {
 	if !d.IsExistingKey {
 		return nil
 	}
 
 	// Verify the remote key exists
-	key, err := d.getKey()
+	key, err := d.getKey(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get key: %w", err)
 	}
@@ -62,7 +62,7 @@ func (d *Driver) copySSHKeyPair(src string) error {
 	return nil
 }
 
-func (d *Driver) createRemoteKeys() error {
+func (d *Driver) createRemoteKeys(ctx context.Context) error {
 	if d.KeyID == 0 {
 		log.Info("Creating SSH key...")
 
@@ -71,14 +71,14 @@ func (d *Driver) createRemoteKeys() error {
 			return fmt.Errorf("could not read ssh public key: %w", err)
 		}
 
-		key, err := d.getRemoteKeyWithSameFingerprintNullable(buf)
+		key, err := d.getRemoteKeyWithSameFingerprintNullable(ctx, buf)
 		if err != nil {
 			return fmt.Errorf("error retrieving potentially existing key: %w", err)
 		}
 		if key == nil {
 			logging.Step("SSH key not found in Hetzner, uploading...")
 
-			key, err = d.makeKey(d.GetMachineName(), string(buf), d.keyLabels)
+			key, err = d.makeKey(ctx, d.GetMachineName(), string(buf), d.keyLabels)
 			if err != nil {
 				return err
 			}
@@ -99,13 +99,13 @@ func (d *Driver) createRemoteKeys() error {
 		}
 
 		// Check if key already exists in Hetzner
-		key, err := d.getRemoteKeyWithSameFingerprintNullable(buf)
+		key, err := d.getRemoteKeyWithSameFingerprintNullable(ctx, buf)
 		if err != nil {
 			return fmt.Errorf("error checking for existing local key: %w", err)
 		}
 
 		if key == nil {
-			key, err = d.makeKey(fmt.Sprintf("%v-local", d.GetMachineName()), string(buf), d.keyLabels)
+			key, err = d.makeKey(ctx, fmt.Sprintf("%v-local", d.GetMachineName()), string(buf), d.keyLabels)
 			if err != nil {
 				return fmt.Errorf("error creating local key: %w", err)
 			}
@@ -119,13 +119,13 @@ func (d *Driver) createRemoteKeys() error {
 	}
 
 	for i, pubkey := range d.AdditionalKeys {
-		key, err := d.getRemoteKeyWithSameFingerprintNullable([]byte(pubkey))
+		key, err := d.getRemoteKeyWithSameFingerprintNullable(ctx, []byte(pubkey))
 		if err != nil {
 			return fmt.Errorf("error checking for existing key for %v: %w", pubkey, err)
 		}
 		if key == nil {
 			logging.Step("Creating additional key #%d...", i)
-			key, err = d.makeKey(fmt.Sprintf("%v-additional-%d", d.GetMachineName(), i), pubkey, d.keyLabels)
+			key, err = d.makeKey(ctx, fmt.Sprintf("%v-additional-%d", d.GetMachineName(), i), pubkey, d.keyLabels)
 
 			if err != nil {
 				return fmt.Errorf("error creating new key for %v: %w", pubkey, err)
@@ -158,23 +158,26 @@ func (d *Driver) prepareLocalKey() error {
 }
 
 // Creates a new key for the machine and appends it to the dangling key list
-func (d *Driver) makeKey(name string, pubkey string, labels map[string]string) (*hcloud.SSHKey, error) {
+func (d *Driver) makeKey(ctx context.Context, name string, pubkey string, labels map[string]string) (*hcloud.SSHKey, error) {
 	keyopts := hcloud.SSHKeyCreateOpts{
 		Name:      name,
 		PublicKey: pubkey,
-		Labels:    labels,
+		Labels:    d.withOwnershipLabels(labels),
 	}
 
-	key, err := d.getClient().CreateSSHKey(context.Background(), instrumented(keyopts))
+	key, err := d.getClient().CreateSSHKey(ctx, instrumented(keyopts))
 	if err != nil {
 		return nil, err
 	}
 
-	d.dangling = append(d.dangling, func() {
-		err := d.getClient().DeleteSSHKey(context.Background(), key)
+	d.journalRecord(journalKindSSHKey, key.ID, key.Name)
+	d.dangling = append(d.dangling, func(ctx context.Context) {
+		err := d.getClient().DeleteSSHKey(ctx, key)
 		if err != nil {
 			log.Error(fmt.Errorf("could not delete ssh key: %w", err))
+			return
 		}
+		d.journalForget(journalKindSSHKey, key.ID)
 	})
 
 	return key, nil
@@ -0,0 +1,24 @@
+package driver
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// parseTemplate parses a user-supplied template, such as a resource name; referencing unknown fields is an error
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v template %q: %w", name, text, err)
+	}
+	return tmpl, nil
+}
+
+func renderTemplate(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("could not render %v: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
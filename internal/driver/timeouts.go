@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	errOperationTimeout      = errors.New("operation exceeded operation-timeout")
+	errActionTimeout         = errors.New("action exceeded action-timeout")
+	errWaitForRunningTimeout = errors.New("server exceeded wait-for-running-timeout")
+	errWaitForNetworkTimeout = errors.New("server exceeded wait-for-network-timeout")
+	errAddressRefreshTimeout = errors.New("address refresh timed out")
+)
+
+// addressRefreshTimeout bounds the server lookup of GetIP, which docker-machine calls for most commands, independent
+// of --hetzner-operation-timeout; the stored address is used once exceeded
+var addressRefreshTimeout = 10 * time.Second
+
+// withTimeout bounds ctx by the given amount of seconds, reporting cause once exceeded; non-positive
+// values leave ctx unbounded
+func withTimeout(ctx context.Context, seconds int, cause error) (context.Context, context.CancelFunc) {
+	if seconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, time.Duration(seconds)*time.Second, cause)
+}
+
+// operationContext creates the context for a single driver operation, such as Create or Remove
+func (d *Driver) operationContext() (context.Context, context.CancelFunc) {
+	return withTimeout(context.Background(), d.OperationTimeout, errOperationTimeout)
+}
+
+// waitPolling sleeps for the polling period, returning early if ctx is done
+func (d *Driver) waitPolling(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-time.After(time.Duration(d.WaitOnPolling) * time.Second):
+		return nil
+	}
+}
+
+// contextError prefers the cause of ctx being done over err, which usually just reports the cancellation
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w (%v)", context.Cause(ctx), err)
+	}
+	return err
+}
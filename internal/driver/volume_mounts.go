@@ -0,0 +1,98 @@
+package driver
+
+import (
+	"fmt"
+	"slices"
+	"text/template"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// defaultMountPoint mirrors the mount points used by Hetzner's own automount
+const defaultMountPoint = "/mnt/HC_Volume_{{.ID}}"
+
+type mountPointData struct {
+	Name  string
+	ID    int64
+	Index int
+}
+
+// usesCloudInitMounts reports whether attached volumes are formatted and mounted through cloud-init rather than
+// Hetzner's automount, which can neither format volumes nor mount them elsewhere
+func (d *Driver) usesCloudInitMounts() bool {
+	return d.volumeAutomount && (d.volumeMountPoint != nil || d.volumeFormat != "")
+}
+
+func (d *Driver) verifyVolumeMountFlags() error {
+	if (d.volumeMountPoint != nil || d.volumeFormat != "") && !d.volumeAutomount {
+		return d.flagFailure("--%v and --%v require --%v", flagVolumeMountPoint, flagVolumeFormat, flagVolumeAutomount)
+	}
+	if d.volumeFormat != "" && !slices.Contains(volumeFormats, d.volumeFormat) {
+		return d.flagFailure("--%v must be one of %v", flagVolumeFormat, volumeFormats)
+	}
+	return nil
+}
+
+// setVolumeMounts enables mounting of the volumes attached at creation, either by Hetzner or by injecting
+// cloud-init `fs_setup` and `mounts` entries into the user data
+func (d *Driver) setVolumeMounts(srvopts *hcloud.ServerCreateOpts) error {
+	if !d.volumeAutomount || len(srvopts.Volumes) == 0 {
+		return nil
+	}
+
+	if !d.usesCloudInitMounts() {
+		srvopts.Automount = hcloud.Ptr(true)
+		return nil
+	}
+
+	mountPoint := d.volumeMountPoint
+	if mountPoint == nil {
+		mountPoint = template.Must(parseTemplate("mount point", defaultMountPoint))
+	}
+
+	mounts, err := volumeMountsCloudConfig(srvopts.Volumes, mountPoint, d.volumeFormat)
+	if err != nil {
+		return err
+	}
+	if err = addCloudConfig(srvopts, mounts); err != nil {
+		return fmt.Errorf("could not add volume mounts to user data: %w", err)
+	}
+	return nil
+}
+
+// volumeMountsCloudConfig returns the cloud-config formatting (if requested) and mounting the given volumes
+func volumeMountsCloudConfig(volumes []*hcloud.Volume, mountPoint *template.Template, format string) (map[string]interface{}, error) {
+	fsType := format
+	if fsType == "" {
+		fsType = "auto"
+	}
+
+	var fsSetup, mounts []interface{}
+	for i, volume := range volumes {
+		path, err := renderTemplate(mountPoint, mountPointData{Name: volume.Name, ID: volume.ID, Index: i})
+		if err != nil {
+			return nil, err
+		}
+
+		device := volume.LinuxDevice
+		if device == "" {
+			device = fmt.Sprintf("/dev/disk/by-id/scsi-0HC_Volume_%d", volume.ID)
+		}
+
+		if format != "" {
+			// existing filesystems are left alone
+			fsSetup = append(fsSetup, map[string]interface{}{
+				"device":     device,
+				"filesystem": format,
+				"overwrite":  false,
+			})
+		}
+		mounts = append(mounts, []interface{}{device, path, fsType, "discard,nofail,defaults", "0", "0"})
+	}
+
+	cloudConfig := map[string]interface{}{"mounts": mounts}
+	if len(fsSetup) != 0 {
+		cloudConfig["fs_setup"] = fsSetup
+	}
+	return cloudConfig, nil
+}
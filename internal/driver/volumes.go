@@ -0,0 +1,227 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	defaultVolumeName = "{{.MachineName}}-volume-{{.Index}}"
+	minVolumeSize     = 10
+)
+
+// volumeSpecSeparator separates the key=value pairs of a volume spec; commas cannot be used, as HETZNER_CREATE_VOLUMES
+// is split at them
+const volumeSpecSeparator = ":"
+
+var volumeFormats = []string{"ext4", "xfs"}
+
+// volumeSpec describes a volume created for each machine, parsed from e.g. `size=50:name={{.MachineName}}-data:format=ext4`
+type volumeSpec struct {
+	Size      int
+	Name      *template.Template
+	Format    string
+	Automount bool
+}
+
+type volumeNameData struct {
+	MachineName string
+	Index       int
+}
+
+func parseVolumeSpec(raw string) (volumeSpec, error) {
+	spec := volumeSpec{}
+	name := defaultVolumeName
+
+	for _, item := range strings.Split(raw, volumeSpecSeparator) {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return spec, fmt.Errorf("expected key=value, got %q", item)
+		}
+
+		var err error
+		switch key = strings.TrimSpace(key); key {
+		case "size":
+			spec.Size, err = strconv.Atoi(value)
+		case "name":
+			name = value
+		case "format":
+			if !slices.Contains(volumeFormats, value) {
+				err = fmt.Errorf("unsupported format %q, expected one of %v", value, volumeFormats)
+			}
+			spec.Format = value
+		case "automount":
+			spec.Automount, err = strconv.ParseBool(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return spec, fmt.Errorf("invalid volume spec %q: %w", raw, err)
+		}
+	}
+
+	if spec.Size < minVolumeSize {
+		return spec, fmt.Errorf("invalid volume spec %q: size must be at least %d GB", raw, minVolumeSize)
+	}
+
+	tmpl, err := parseTemplate("volume name", name)
+	if err != nil {
+		return spec, err
+	}
+	spec.Name = tmpl
+
+	// catch references to unknown fields early
+	if _, err = spec.name("machine", 0); err != nil {
+		return spec, err
+	}
+	return spec, nil
+}
+
+func (s volumeSpec) name(machineName string, index int) (string, error) {
+	return renderTemplate(s.Name, volumeNameData{MachineName: machineName, Index: index})
+}
+
+// verifyVolumeNamesFree fails early if a volume to be created already exists, e.g. one kept by --hetzner-keep-volumes
+// for an earlier machine of the same name, as its creation would otherwise only fail after the server was created
+func (d *Driver) verifyVolumeNamesFree(ctx context.Context) error {
+	for i, spec := range d.volumeSpecs {
+		name, err := spec.name(d.GetMachineName(), i)
+		if err != nil {
+			return err
+		}
+
+		volume, err := d.getClient().GetVolumeByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if volume != nil {
+			return fmt.Errorf("volume %s already exists; delete it, or change its name with --%v", logging.Key(volume.Name, volume.ID), flagCreateVolume)
+		}
+	}
+	return nil
+}
+
+// createMachineVolumes creates and attaches the configured per-machine volumes in the server's location
+func (d *Driver) createMachineVolumes(ctx context.Context, srv *hcloud.Server) error {
+	for i, spec := range d.volumeSpecs {
+		name, err := spec.name(d.GetMachineName(), i)
+		if err != nil {
+			return err
+		}
+
+		logging.Step("Creating volume %s (%d GB)...", name, spec.Size)
+
+		opts := hcloud.VolumeCreateOpts{
+			Name:      name,
+			Size:      spec.Size,
+			Server:    srv,
+			Labels:    d.withOwnershipLabels(map[string]string{config.LabelName(config.LabelAutoCreated): "true"}),
+			Automount: hcloud.Ptr(spec.Automount),
+		}
+		if spec.Format != "" {
+			opts.Format = hcloud.Ptr(spec.Format)
+		}
+
+		result, err := d.getClient().CreateVolume(ctx, instrumented(opts))
+		if err != nil {
+			return err
+		}
+		d.makeVolumeDangling(result.Volume)
+		d.CreatedVolumeIDs = append(d.CreatedVolumeIDs, result.Volume.ID)
+
+		var actions []*hcloud.Action
+		for _, action := range append([]*hcloud.Action{result.Action}, result.NextActions...) {
+			if action != nil {
+				actions = append(actions, action)
+			}
+		}
+		if err = d.waitForMultipleActions(ctx, "volume.create", actions); err != nil {
+			return fmt.Errorf("could not wait for volume %v: %w", name, err)
+		}
+		logging.Substep("Created volume %s", logging.Key(result.Volume.Name, result.Volume.ID))
+	}
+	return nil
+}
+
+// makeVolumeDangling registers a freshly created volume for removal should creation fail later on
+func (d *Driver) makeVolumeDangling(volume *hcloud.Volume) {
+	d.journalRecord(journalKindVolume, volume.ID, volume.Name)
+	d.dangling = append(d.dangling, func(ctx context.Context) {
+		logging.Step("Rolling back volume %s", logging.Key(volume.Name, volume.ID))
+
+		if err := d.destroyVolume(ctx, volume.ID); err != nil {
+			log.Errorf("Could not delete volume: %v", err)
+			return
+		}
+
+		d.CreatedVolumeIDs = slices.DeleteFunc(d.CreatedVolumeIDs, func(id int64) bool { return id == volume.ID })
+		d.journalForget(journalKindVolume, volume.ID)
+	})
+}
+
+// destroyVolume detaches the volume if necessary and deletes it; volumes that no longer exist are ignored
+func (d *Driver) destroyVolume(ctx context.Context, id int64) error {
+	volume, err := d.getClient().GetVolumeByID(ctx, id)
+	if err != nil || volume == nil {
+		return err
+	}
+
+	if volume.Server != nil {
+		action, err := d.getClient().DetachVolume(ctx, volume)
+		if err != nil {
+			return err
+		}
+		if err = d.waitForAction(ctx, action); err != nil {
+			return fmt.Errorf("could not wait for detachment: %w", err)
+		}
+	}
+
+	return d.getClient().DeleteVolume(ctx, volume)
+}
+
+// releaseVolume drops the instance label of a retained volume, so it is no longer considered owned by the machine
+func (d *Driver) releaseVolume(ctx context.Context, id int64) error {
+	volume, err := d.getClient().GetVolumeByID(ctx, id)
+	if err != nil || volume == nil {
+		return err
+	}
+
+	labels := maps.Clone(volume.Labels)
+	delete(labels, config.LabelName(config.LabelInstanceID))
+	delete(labels, config.LabelName(config.LabelAutoCreated))
+
+	_, err = d.getClient().UpdateVolume(ctx, volume, hcloud.VolumeUpdateOpts{Labels: labels})
+	return err
+}
+
+// removeMachineVolumes deletes or, if requested, retains the volumes created for the machine
+func (d *Driver) removeMachineVolumes(ctx context.Context) {
+	for _, id := range d.CreatedVolumeIDs {
+		var err error
+		if d.KeepVolumes {
+			logging.Step("Keeping volume [ID: %d]", id)
+			err = d.releaseVolume(ctx, id)
+		} else {
+			logging.Step("Destroying volume [ID: %d]", id)
+			err = d.destroyVolume(ctx, id)
+		}
+
+		// failure to clean up a volume is not a hard error
+		if err != nil {
+			logging.WarnStep("Could not clean up volume [ID: %d]: %v", id, err)
+		}
+	}
+}
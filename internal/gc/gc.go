@@ -0,0 +1,318 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// Command is the name of the subcommand, passed as first argument to the driver binary
+const Command = "gc"
+
+// ownerSelector matches every resource carrying the driver's ownership labels
+var ownerSelector = config.LabelName(config.LabelInstanceID)
+
+type options struct {
+	token       string
+	storagePath string
+	keep        []string
+	apply       bool
+}
+
+// Run executes the gc subcommand with the arguments following it and returns the process exit code
+func Run(args []string, version string) int {
+	opts, err := parseArgs(args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	client := hetzner.NewClient(hetzner.ClientConfig{
+		Token:      opts.token,
+		AppName:    "docker-machine-driver",
+		AppVersion: version,
+	})
+
+	if err := run(ctx, client, opts, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func parseArgs(args []string, output io.Writer) (options, error) {
+	var opts options
+
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: docker-machine-driver-hetzner %s [options]\n\n", Command)
+		fmt.Fprintf(output, "Lists resources created by this driver whose machine no longer exists, and deletes them with --apply.\n\n")
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&opts.token, "token", os.Getenv("HETZNER_API_TOKEN"), "Hetzner Cloud API token (env: HETZNER_API_TOKEN)")
+	fs.StringVar(&opts.storagePath, "storage-path", os.Getenv("MACHINE_STORAGE_PATH"), "docker-machine store directory whose machines are kept (env: MACHINE_STORAGE_PATH)")
+	fs.Func("keep", "Machine name whose resources are kept; may be repeated or comma-separated", func(value string) error {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.keep = append(opts.keep, name)
+			}
+		}
+		return nil
+	})
+	fs.BoolVar(&opts.apply, "apply", false, "Delete orphaned resources instead of only reporting them")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() != 0 {
+		return opts, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if opts.token == "" {
+		return opts, errors.New("missing API token, use --token or HETZNER_API_TOKEN")
+	}
+	if opts.storagePath == "" && len(opts.keep) == 0 {
+		// without either, every labelled resource would be considered orphaned
+		return opts, errors.New("either --storage-path or --keep is required")
+	}
+	return opts, nil
+}
+
+func run(ctx context.Context, client *hetzner.Client, opts options, out io.Writer) error {
+	owners := make(owners)
+	if opts.storagePath != "" {
+		if err := owners.addStore(opts.storagePath); err != nil {
+			return err
+		}
+	}
+	for _, name := range opts.keep {
+		owners.add(name, "")
+	}
+
+	inv, err := listOwnedResources(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	orphans := inv.orphans(owners)
+	if len(orphans) == 0 {
+		fmt.Fprintln(out, "No orphaned resources found")
+		return nil
+	}
+
+	for _, orphan := range orphans {
+		fmt.Fprintf(out, "orphaned %s\n", orphan)
+	}
+	if !opts.apply {
+		fmt.Fprintf(out, "%d orphaned resource(s) found, run with --apply to delete them\n", len(orphans))
+		return nil
+	}
+
+	failed := 0
+	for _, orphan := range orphans {
+		if err := orphan.delete(ctx, client); err != nil && !hetzner.IsNotFoundError(err) {
+			fmt.Fprintf(out, "could not delete %s: %v\n", orphan, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "deleted %s\n", orphan)
+	}
+	if failed != 0 {
+		return fmt.Errorf("could not delete %d resource(s)", failed)
+	}
+	return nil
+}
+
+// inventory holds all resources carrying the driver's ownership labels
+type inventory struct {
+	servers    []*hcloud.Server
+	groups     []*hcloud.PlacementGroup
+	volumes    []*hcloud.Volume
+	primaryIPs []*hcloud.PrimaryIP
+	firewalls  []*hcloud.Firewall
+	networks   []*hcloud.Network
+	keys       []*hcloud.SSHKey
+}
+
+func listOwnedResources(ctx context.Context, client *hetzner.Client) (inventory, error) {
+	var inv inventory
+	var err error
+
+	if inv.servers, err = client.GetServersByLabel(ctx, ownerSelector); err != nil {
+		return inv, err
+	}
+	if inv.groups, err = client.GetPlacementGroupsByLabel(ctx, ownerSelector); err != nil {
+		return inv, err
+	}
+	if inv.volumes, err = client.GetVolumesByLabel(ctx, ownerSelector); err != nil {
+		return inv, err
+	}
+	if inv.primaryIPs, err = client.GetPrimaryIPsByLabel(ctx, ownerSelector); err != nil {
+		return inv, err
+	}
+	if inv.firewalls, err = client.GetFirewallsByLabel(ctx, ownerSelector); err != nil {
+		return inv, err
+	}
+	if inv.networks, err = client.GetNetworksByLabel(ctx, ownerSelector); err != nil {
+		return inv, err
+	}
+	if inv.keys, err = client.GetSSHKeysByLabel(ctx, ownerSelector); err != nil {
+		return inv, err
+	}
+	return inv, nil
+}
+
+// orphans returns all resources not owned by a known machine, in the order they can be deleted in. Resources
+// still in use by a server that is kept are left alone.
+func (inv inventory) orphans(owners owners) []orphan {
+	var result []orphan
+	deleted := make(map[int64]bool)
+
+	for _, srv := range inv.servers {
+		// adopted servers were not created by the driver and are never deleted
+		if !owners.owns(srv.Labels) && srv.Labels[config.LabelName(config.LabelAdopted)] != "true" {
+			deleted[srv.ID] = true
+			result = append(result, orphan{"server", srv.Name, srv.ID, srv.Labels, func(ctx context.Context, c *hetzner.Client) error {
+				action, err := c.DeleteServer(ctx, srv)
+				if err != nil {
+					return err
+				}
+				return c.WaitForAction(ctx, action)
+			}})
+		}
+	}
+
+	for _, grp := range inv.groups {
+		if !owners.owns(grp.Labels) && allDeleted(grp.Servers, deleted) {
+			result = append(result, orphan{"placement group", grp.Name, grp.ID, grp.Labels, func(ctx context.Context, c *hetzner.Client) error {
+				return c.DeletePlacementGroup(ctx, grp)
+			}})
+		}
+	}
+
+	for _, volume := range inv.volumes {
+		if !owners.owns(volume.Labels) && (volume.Server == nil || deleted[volume.Server.ID]) {
+			result = append(result, orphan{"volume", volume.Name, volume.ID, volume.Labels, func(ctx context.Context, c *hetzner.Client) error {
+				return c.DeleteVolume(ctx, volume)
+			}})
+		}
+	}
+
+	for _, ip := range inv.primaryIPs {
+		if !owners.owns(ip.Labels) && (ip.AssigneeID == 0 || deleted[ip.AssigneeID]) {
+			result = append(result, orphan{"primary IP", ip.Name, ip.ID, ip.Labels, func(ctx context.Context, c *hetzner.Client) error {
+				return c.DeletePrimaryIP(ctx, ip)
+			}})
+		}
+	}
+
+	for _, firewall := range inv.firewalls {
+		if !owners.owns(firewall.Labels) && allDeleted(firewallServers(firewall), deleted) {
+			result = append(result, orphan{"firewall", firewall.Name, firewall.ID, firewall.Labels, deleteFirewall(firewall.ID)})
+		}
+	}
+
+	for _, network := range inv.networks {
+		if !owners.owns(network.Labels) && allDeleted(serverIDs(network.Servers), deleted) {
+			result = append(result, orphan{"network", network.Name, network.ID, network.Labels, func(ctx context.Context, c *hetzner.Client) error {
+				return c.DeleteNetwork(ctx, network)
+			}})
+		}
+	}
+
+	for _, key := range inv.keys {
+		if !owners.owns(key.Labels) {
+			result = append(result, orphan{"SSH key", key.Name, key.ID, key.Labels, func(ctx context.Context, c *hetzner.Client) error {
+				return c.DeleteSSHKey(ctx, key)
+			}})
+		}
+	}
+
+	return result
+}
+
+func serverIDs(servers []*hcloud.Server) []int64 {
+	ids := make([]int64, 0, len(servers))
+	for _, srv := range servers {
+		ids = append(ids, srv.ID)
+	}
+	return ids
+}
+
+// firewallServers returns the IDs of all servers the firewall is applied to, directly or by label selector
+func firewallServers(firewall *hcloud.Firewall) []int64 {
+	var ids []int64
+	for _, res := range firewall.AppliedTo {
+		if res.Server != nil {
+			ids = append(ids, res.Server.ID)
+		}
+		for _, applied := range res.AppliedToResources {
+			if applied.Server != nil {
+				ids = append(ids, applied.Server.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// deleteFirewall detaches the firewall from the resources it is still applied to, which the API requires before
+// deletion. The firewall is fetched again, as servers deleted before have been detached already.
+func deleteFirewall(id int64) func(context.Context, *hetzner.Client) error {
+	return func(ctx context.Context, c *hetzner.Client) error {
+		firewall, err := c.GetFirewallByID(ctx, id)
+		if err != nil || firewall == nil {
+			return err
+		}
+
+		if len(firewall.AppliedTo) != 0 {
+			resources := make([]hcloud.FirewallResource, 0, len(firewall.AppliedTo))
+			for _, res := range firewall.AppliedTo {
+				resources = append(resources, hcloud.FirewallResource{Type: res.Type, Server: res.Server, LabelSelector: res.LabelSelector})
+			}
+			actions, err := c.RemoveFirewallFromResources(ctx, firewall, resources)
+			if err != nil {
+				return err
+			}
+			if err := c.WaitForActions(ctx, "remove firewall from resources", actions); err != nil {
+				return err
+			}
+		}
+		return c.DeleteFirewall(ctx, firewall)
+	}
+}
+
+func allDeleted(ids []int64, deleted map[int64]bool) bool {
+	for _, id := range ids {
+		if !deleted[id] {
+			return false
+		}
+	}
+	return true
+}
+
+type orphan struct {
+	kind   string
+	name   string
+	id     int64
+	labels map[string]string
+	delete func(context.Context, *hetzner.Client) error
+}
+
+func (o orphan) String() string {
+	return fmt.Sprintf("%s %s [ID: %d] (machine: %s)", o.kind, o.name, o.id, o.labels[config.LabelName(config.LabelMachineName)])
+}
@@ -0,0 +1,179 @@
+package gc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func ownedBy(machine, instanceID string) map[string]string {
+	return map[string]string{
+		config.LabelName(config.LabelMachineName): machine,
+		config.LabelName(config.LabelInstanceID):  instanceID,
+	}
+}
+
+func TestAddStore(t *testing.T) {
+	store := t.TempDir()
+	for name, cfg := range map[string]string{
+		"current": `{"Driver": {"MachineName": "current", "InstanceID": "abc"}}`,
+		"legacy":  `{"Driver": {"MachineName": "legacy"}}`,
+	} {
+		dir := filepath.Join(store, "machines", name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := make(owners)
+	if err := o.addStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		labels   map[string]string
+		expected bool
+	}{
+		{ownedBy("current", "abc"), true},
+		{ownedBy("current", "old"), false},
+		{ownedBy("legacy", "anything"), true},
+		{ownedBy("gone", "abc"), false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if result := o.owns(tt.labels); result != tt.expected {
+			t.Errorf("owns(%v) = %v, want %v", tt.labels, result, tt.expected)
+		}
+	}
+
+	if err := make(owners).addStore(filepath.Join(store, "missing")); err == nil {
+		t.Errorf("expected error for missing store")
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	o := make(owners)
+	o.add("kept", "")
+
+	inv := inventory{
+		servers: []*hcloud.Server{
+			{ID: 1, Name: "kept", Labels: ownedBy("kept", "a")},
+			{ID: 2, Name: "gone", Labels: ownedBy("gone", "b")},
+		},
+		groups: []*hcloud.PlacementGroup{
+			{ID: 10, Name: "shared", Labels: ownedBy("gone", "b"), Servers: []int64{1, 2}},
+			{ID: 11, Name: "unused", Labels: ownedBy("gone", "b"), Servers: []int64{2}},
+		},
+		volumes: []*hcloud.Volume{
+			{ID: 20, Name: "attached", Labels: ownedBy("other", "c"), Server: &hcloud.Server{ID: 1}},
+			{ID: 21, Name: "detached", Labels: ownedBy("other", "c")},
+		},
+		primaryIPs: []*hcloud.PrimaryIP{
+			{ID: 30, Name: "assigned", Labels: ownedBy("gone", "b"), AssigneeID: 2},
+		},
+		firewalls: []*hcloud.Firewall{
+			{ID: 50, Name: "gone-docker", Labels: ownedBy("gone", "b"), AppliedTo: []hcloud.FirewallResource{
+				{Type: hcloud.FirewallResourceTypeServer, Server: &hcloud.FirewallResourceServer{ID: 2}},
+			}},
+			{ID: 51, Name: "shared-rules", Labels: ownedBy("gone", "b"), AppliedTo: []hcloud.FirewallResource{
+				{Type: hcloud.FirewallResourceTypeLabelSelector, AppliedToResources: []hcloud.FirewallResource{
+					{Type: hcloud.FirewallResourceTypeServer, Server: &hcloud.FirewallResourceServer{ID: 1}},
+				}},
+			}},
+		},
+		networks: []*hcloud.Network{
+			{ID: 60, Name: "shared", Labels: ownedBy("gone", "b"), Servers: []*hcloud.Server{{ID: 1}, {ID: 2}}},
+			{ID: 61, Name: "unused", Labels: ownedBy("gone", "b"), Servers: []*hcloud.Server{{ID: 2}}},
+		},
+		keys: []*hcloud.SSHKey{
+			{ID: 40, Name: "gone-additional-0", Labels: ownedBy("gone", "b")},
+			{ID: 41, Name: "kept", Labels: ownedBy("kept", "a")},
+		},
+	}
+
+	var ids []int64
+	for _, orphan := range inv.orphans(o) {
+		ids = append(ids, orphan.id)
+	}
+
+	expected := []int64{2, 11, 21, 30, 50, 61, 40}
+	if len(ids) != len(expected) {
+		t.Fatalf("orphans = %v, want %v", ids, expected)
+	}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Errorf("orphans = %v, want %v", ids, expected)
+			break
+		}
+	}
+}
+
+func TestDeleteFirewall(t *testing.T) {
+	responses := map[string]string{
+		"GET /firewalls/50": `{"firewall": {"id": 50, "name": "gone-docker", "applied_to": [
+			{"type": "label_selector", "label_selector": {"selector": "role=web"}}
+		]}}`,
+		"POST /firewalls/50/actions/remove_from_resources": `{"actions": [{"id": 1, "status": "success"}]}`,
+		"DELETE /firewalls/50":                             ``,
+	}
+
+	var calls []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		calls = append(calls, key)
+
+		w.Header().Set("Content-Type", "application/json")
+		body, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"error": {"code": "not_found", "message": "not found"}}`
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	defer api.Close()
+
+	client := hetzner.NewClient(hetzner.ClientConfig{AdditionalOpts: []hcloud.ClientOption{hcloud.WithEndpoint(api.URL)}})
+	if err := deleteFirewall(50)(context.Background(), client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the firewall must be detached before it can be deleted
+	detach := slices.Index(calls, "POST /firewalls/50/actions/remove_from_resources")
+	remove := slices.Index(calls, "DELETE /firewalls/50")
+	if detach < 0 || remove < detach {
+		t.Errorf("expected detach before delete, got calls %v", calls)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	t.Setenv("HETZNER_API_TOKEN", "")
+	t.Setenv("MACHINE_STORAGE_PATH", "")
+
+	if _, err := parseArgs([]string{"--keep", "a"}, io.Discard); err == nil {
+		t.Errorf("expected error without token")
+	}
+	if _, err := parseArgs([]string{"--token", "t"}, io.Discard); err == nil {
+		t.Errorf("expected error without store or allow-list")
+	}
+
+	opts, err := parseArgs([]string{"--token", "t", "--keep", "a, b", "--keep", "c", "--apply"}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(opts.keep) != 3 || opts.keep[1] != "b" || !opts.apply {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+}
@@ -0,0 +1,73 @@
+package gc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+)
+
+// owners maps the label value of each known machine name to its instance ID; an empty instance ID
+// matches resources of any instance with that name
+type owners map[string]string
+
+func (o owners) add(machineName, instanceID string) {
+	name := config.LabelValue(machineName)
+	if existing, ok := o[name]; ok && existing != instanceID {
+		// ambiguous, e.g. the same machine name in two stores
+		instanceID = ""
+	}
+	o[name] = instanceID
+}
+
+// owns reports whether a resource with the given labels belongs to a known machine. Instance IDs are compared
+// so resources of an earlier machine with the same name are still considered orphaned.
+func (o owners) owns(labels map[string]string) bool {
+	instanceID, ok := o[labels[config.LabelName(config.LabelMachineName)]]
+	return ok && (instanceID == "" || instanceID == labels[config.LabelName(config.LabelInstanceID)])
+}
+
+// machineConfig is the part of a docker-machine config.json relevant to ownership
+type machineConfig struct {
+	Driver struct {
+		MachineName string
+		InstanceID  string
+	}
+}
+
+// addStore registers every machine found in a docker-machine store directory
+func (o owners) addStore(storagePath string) error {
+	dir := filepath.Join(storagePath, "machines")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("could not read machine store: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		buf, err := os.ReadFile(filepath.Join(dir, entry.Name(), "config.json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("could not read machine config: %w", err)
+		}
+
+		var cfg machineConfig
+		if err := json.Unmarshal(buf, &cfg); err != nil {
+			return fmt.Errorf("could not parse config of machine %v: %w", entry.Name(), err)
+		}
+
+		name := cfg.Driver.MachineName
+		if name == "" {
+			name = entry.Name()
+		}
+		o.add(name, cfg.Driver.InstanceID)
+	}
+	return nil
+}
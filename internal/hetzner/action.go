@@ -8,7 +8,12 @@ import (
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
+// WaitForAction waits for action to complete; a nil action is considered complete
 func (c *Client) WaitForAction(ctx context.Context, action *hcloud.Action) error {
+	if action == nil {
+		return nil
+	}
+
 	lastProgress := 0
 	
 	err := c.hcloud.Action.WaitForFunc(ctx, func(update *hcloud.Action) error {
@@ -34,6 +39,10 @@ func (c *Client) WaitForActions(ctx context.Context, stepName string, actions []
 	logging.DebugStep("%s: starting", stepName)
 
 	for _, action := range actions {
+		if action == nil {
+			continue
+		}
+
 		lastProgress := 0
 		
 		err := c.hcloud.Action.WaitForFunc(ctx, func(update *hcloud.Action) error {
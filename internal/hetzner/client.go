@@ -9,6 +9,7 @@ import (
 type Client struct {
 	hcloud       *hcloud.Client
 	pollInterval time.Duration
+	retryPolicy  RetryPolicy
 }
 
 type ClientConfig struct {
@@ -16,6 +17,7 @@ type ClientConfig struct {
 	AppName         string
 	AppVersion      string
 	PollInterval    time.Duration
+	Retry           RetryPolicy
 	AdditionalOpts  []hcloud.ClientOption
 }
 
@@ -32,11 +34,17 @@ func NewClient(cfg ClientConfig) *Client {
 		}),
 	}
 	
+	if cfg.Retry.enabled() {
+		// retries are handled by the client itself, so creations are not repeated blindly
+		opts = append(opts, hcloud.WithRetryOpts(hcloud.RetryOpts{MaxRetries: 0}))
+	}
+
 	opts = append(opts, cfg.AdditionalOpts...)
 	
 	return &Client{
 		hcloud:       hcloud.NewClient(opts...),
 		pollInterval: cfg.PollInterval,
+		retryPolicy:  cfg.Retry,
 	}
 }
 
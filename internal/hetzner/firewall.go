@@ -0,0 +1,118 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (c *Client) GetFirewallByID(ctx context.Context, id int64) (*hcloud.Firewall, error) {
+	firewall, err := withRetry(ctx, c, func() (*hcloud.Firewall, *hcloud.Response, error) {
+		return c.hcloud.Firewall.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get firewall by ID: %w", err)
+	}
+	return firewall, nil
+}
+
+func (c *Client) GetFirewallByName(ctx context.Context, name string) (*hcloud.Firewall, error) {
+	firewall, err := withRetry(ctx, c, func() (*hcloud.Firewall, *hcloud.Response, error) {
+		return c.hcloud.Firewall.GetByName(ctx, name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get firewall by name: %w", err)
+	}
+	return firewall, nil
+}
+
+func (c *Client) GetFirewallsByLabel(ctx context.Context, labelSelector string) ([]*hcloud.Firewall, error) {
+	firewalls, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.Firewall, error) {
+		return c.hcloud.Firewall.AllWithOpts(ctx, hcloud.FirewallListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+		})
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not list firewalls: %w", err)
+	}
+	return firewalls, nil
+}
+
+func (c *Client) CreateFirewall(ctx context.Context, opts hcloud.FirewallCreateOpts) (*hcloud.Firewall, error) {
+	lookup := func() (*hcloud.Firewall, bool, error) {
+		firewall, _, err := c.hcloud.Firewall.GetByName(ctx, opts.Name)
+		if err != nil || firewall == nil {
+			return nil, false, err
+		}
+		return firewall, true, verifyCreatedByCall(opts.Labels, firewall.Labels)
+	}
+
+	firewall, err := withRetryOrRecover(ctx, c, lookup, func() (*hcloud.Firewall, *hcloud.Response, error) {
+		res, resp, err := c.hcloud.Firewall.Create(ctx, opts)
+		return res.Firewall, resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create firewall: %w", err)
+	}
+	return firewall, nil
+}
+
+func (c *Client) DeleteFirewall(ctx context.Context, firewall *hcloud.Firewall) error {
+	_, err := withRetry(ctx, c, withoutResult(func() (*hcloud.Response, error) {
+		return c.hcloud.Firewall.Delete(ctx, firewall)
+	}))
+	if err != nil {
+		return fmt.Errorf("could not delete firewall: %w", err)
+	}
+	return nil
+}
+
+// SetFirewallRules replaces all rules of the firewall; setting the same rules again is harmless, so the call is
+// simply retried
+func (c *Client) SetFirewallRules(ctx context.Context, firewall *hcloud.Firewall, rules []hcloud.FirewallRule) ([]*hcloud.Action, error) {
+	actions, err := withRetry(ctx, c, func() ([]*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Firewall.SetRules(ctx, firewall, hcloud.FirewallSetRulesOpts{Rules: rules})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not set firewall rules: %w", err)
+	}
+	return actions, nil
+}
+
+func (c *Client) ApplyFirewallToServer(ctx context.Context, firewall *hcloud.Firewall, server *hcloud.Server) ([]*hcloud.Action, error) {
+	actions, err := withRetry(ctx, c, func() ([]*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Firewall.ApplyResources(ctx, firewall, []hcloud.FirewallResource{{
+			Type:   hcloud.FirewallResourceTypeServer,
+			Server: &hcloud.FirewallResourceServer{ID: server.ID},
+		}})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not apply firewall to server: %w", err)
+	}
+	return actions, nil
+}
+
+func (c *Client) RemoveFirewallFromServer(ctx context.Context, firewall *hcloud.Firewall, server *hcloud.Server) ([]*hcloud.Action, error) {
+	actions, err := withRetry(ctx, c, func() ([]*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Firewall.RemoveResources(ctx, firewall, []hcloud.FirewallResource{{
+			Type:   hcloud.FirewallResourceTypeServer,
+			Server: &hcloud.FirewallResourceServer{ID: server.ID},
+		}})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not remove firewall from server: %w", err)
+	}
+	return actions, nil
+}
+
+// RemoveFirewallFromResources detaches the firewall from the given servers and label selectors
+func (c *Client) RemoveFirewallFromResources(ctx context.Context, firewall *hcloud.Firewall, resources []hcloud.FirewallResource) ([]*hcloud.Action, error) {
+	actions, err := withRetry(ctx, c, func() ([]*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Firewall.RemoveResources(ctx, firewall, resources)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not remove firewall from resources: %w", err)
+	}
+	return actions, nil
+}
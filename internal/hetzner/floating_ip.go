@@ -0,0 +1,65 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (c *Client) GetFloatingIP(ctx context.Context, nameOrID string) (*hcloud.FloatingIP, error) {
+	ip, err := withRetry(ctx, c, func() (*hcloud.FloatingIP, *hcloud.Response, error) {
+		return c.hcloud.FloatingIP.Get(ctx, nameOrID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get floating IP by ID or name: %w", err)
+	}
+	if ip == nil {
+		return nil, fmt.Errorf("floating IP '%s' not found", nameOrID)
+	}
+	return ip, nil
+}
+
+func (c *Client) GetFloatingIPByID(ctx context.Context, id int64) (*hcloud.FloatingIP, error) {
+	ip, err := withRetry(ctx, c, func() (*hcloud.FloatingIP, *hcloud.Response, error) {
+		return c.hcloud.FloatingIP.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get floating IP by ID: %w", err)
+	}
+	return ip, nil
+}
+
+func (c *Client) GetFloatingIPsByLabel(ctx context.Context, labelSelector string) ([]*hcloud.FloatingIP, error) {
+	ips, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.FloatingIP, error) {
+		return c.hcloud.FloatingIP.AllWithOpts(ctx, hcloud.FloatingIPListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+		})
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not list floating IPs: %w", err)
+	}
+	return ips, nil
+}
+
+// AssignFloatingIP assigns the floating IP to the server; assigning it to the same server again is harmless, so the
+// call is simply retried
+func (c *Client) AssignFloatingIP(ctx context.Context, ip *hcloud.FloatingIP, server *hcloud.Server) (*hcloud.Action, error) {
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.FloatingIP.Assign(ctx, ip, server)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not assign floating IP: %w", err)
+	}
+	return action, nil
+}
+
+func (c *Client) UnassignFloatingIP(ctx context.Context, ip *hcloud.FloatingIP) (*hcloud.Action, error) {
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.FloatingIP.Unassign(ctx, ip)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not unassign floating IP: %w", err)
+	}
+	return action, nil
+}
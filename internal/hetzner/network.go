@@ -0,0 +1,68 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (c *Client) GetNetworkByID(ctx context.Context, id int64) (*hcloud.Network, error) {
+	network, err := withRetry(ctx, c, func() (*hcloud.Network, *hcloud.Response, error) {
+		return c.hcloud.Network.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get network by ID: %w", err)
+	}
+	return network, nil
+}
+
+func (c *Client) GetNetworkByName(ctx context.Context, name string) (*hcloud.Network, error) {
+	network, err := withRetry(ctx, c, func() (*hcloud.Network, *hcloud.Response, error) {
+		return c.hcloud.Network.GetByName(ctx, name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get network by name: %w", err)
+	}
+	return network, nil
+}
+
+func (c *Client) GetNetworksByLabel(ctx context.Context, labelSelector string) ([]*hcloud.Network, error) {
+	networks, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.Network, error) {
+		return c.hcloud.Network.AllWithOpts(ctx, hcloud.NetworkListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+		})
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not list networks: %w", err)
+	}
+	return networks, nil
+}
+
+func (c *Client) CreateNetwork(ctx context.Context, opts hcloud.NetworkCreateOpts) (*hcloud.Network, error) {
+	lookup := func() (*hcloud.Network, bool, error) {
+		network, _, err := c.hcloud.Network.GetByName(ctx, opts.Name)
+		if err != nil || network == nil {
+			return nil, false, err
+		}
+		return network, true, verifyCreatedByCall(opts.Labels, network.Labels)
+	}
+
+	network, err := withRetryOrRecover(ctx, c, lookup, func() (*hcloud.Network, *hcloud.Response, error) {
+		return c.hcloud.Network.Create(ctx, opts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create network: %w", err)
+	}
+	return network, nil
+}
+
+func (c *Client) DeleteNetwork(ctx context.Context, network *hcloud.Network) error {
+	_, err := withRetry(ctx, c, withoutResult(func() (*hcloud.Response, error) {
+		return c.hcloud.Network.Delete(ctx, network)
+	}))
+	if err != nil {
+		return fmt.Errorf("could not delete network: %w", err)
+	}
+	return nil
+}
@@ -8,7 +8,9 @@ import (
 )
 
 func (c *Client) GetPlacementGroup(ctx context.Context, nameOrID string) (*hcloud.PlacementGroup, error) {
-	grp, _, err := c.hcloud.PlacementGroup.Get(ctx, nameOrID)
+	grp, err := withRetry(ctx, c, func() (*hcloud.PlacementGroup, *hcloud.Response, error) {
+		return c.hcloud.PlacementGroup.Get(ctx, nameOrID)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get placement group: %w", err)
 	}
@@ -16,9 +18,11 @@ func (c *Client) GetPlacementGroup(ctx context.Context, nameOrID string) (*hclou
 }
 
 func (c *Client) GetPlacementGroupsByLabel(ctx context.Context, labelSelector string) ([]*hcloud.PlacementGroup, error) {
-	groups, err := c.hcloud.PlacementGroup.AllWithOpts(ctx, hcloud.PlacementGroupListOpts{
-		ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
-	})
+	groups, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.PlacementGroup, error) {
+		return c.hcloud.PlacementGroup.AllWithOpts(ctx, hcloud.PlacementGroupListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+		})
+	}))
 	if err != nil {
 		return nil, fmt.Errorf("could not list placement groups: %w", err)
 	}
@@ -26,7 +30,17 @@ func (c *Client) GetPlacementGroupsByLabel(ctx context.Context, labelSelector st
 }
 
 func (c *Client) CreatePlacementGroup(ctx context.Context, opts hcloud.PlacementGroupCreateOpts) (*hcloud.PlacementGroup, error) {
-	result, _, err := c.hcloud.PlacementGroup.Create(ctx, opts)
+	lookup := func() (hcloud.PlacementGroupCreateResult, bool, error) {
+		grp, _, err := c.hcloud.PlacementGroup.GetByName(ctx, opts.Name)
+		if err != nil || grp == nil {
+			return hcloud.PlacementGroupCreateResult{}, false, err
+		}
+		return hcloud.PlacementGroupCreateResult{PlacementGroup: grp}, true, verifyCreatedByCall(opts.Labels, grp.Labels)
+	}
+
+	result, err := withRetryOrRecover(ctx, c, lookup, func() (hcloud.PlacementGroupCreateResult, *hcloud.Response, error) {
+		return c.hcloud.PlacementGroup.Create(ctx, opts)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not create placement group: %w", err)
 	}
@@ -34,7 +48,9 @@ func (c *Client) CreatePlacementGroup(ctx context.Context, opts hcloud.Placement
 }
 
 func (c *Client) DeletePlacementGroup(ctx context.Context, pg *hcloud.PlacementGroup) error {
-	_, err := c.hcloud.PlacementGroup.Delete(ctx, pg)
+	_, err := withRetry(ctx, c, withoutResult(func() (*hcloud.Response, error) {
+		return c.hcloud.PlacementGroup.Delete(ctx, pg)
+	}))
 	if err != nil {
 		return fmt.Errorf("could not delete placement group: %w", err)
 	}
@@ -0,0 +1,61 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (c *Client) GetPrimaryIPByID(ctx context.Context, id int64) (*hcloud.PrimaryIP, error) {
+	ip, err := withRetry(ctx, c, func() (*hcloud.PrimaryIP, *hcloud.Response, error) {
+		return c.hcloud.PrimaryIP.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get primary IP by ID: %w", err)
+	}
+	return ip, nil
+}
+
+// GetPrimaryIPByName returns the primary IP of the given name, or nil if there is none
+func (c *Client) GetPrimaryIPByName(ctx context.Context, name string) (*hcloud.PrimaryIP, error) {
+	ip, err := withRetry(ctx, c, func() (*hcloud.PrimaryIP, *hcloud.Response, error) {
+		return c.hcloud.PrimaryIP.GetByName(ctx, name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get primary IP by name: %w", err)
+	}
+	return ip, nil
+}
+
+func (c *Client) GetPrimaryIPsByLabel(ctx context.Context, labelSelector string) ([]*hcloud.PrimaryIP, error) {
+	ips, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.PrimaryIP, error) {
+		return c.hcloud.PrimaryIP.AllWithOpts(ctx, hcloud.PrimaryIPListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+		})
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not list primary IPs: %w", err)
+	}
+	return ips, nil
+}
+
+func (c *Client) UpdatePrimaryIP(ctx context.Context, ip *hcloud.PrimaryIP, opts hcloud.PrimaryIPUpdateOpts) (*hcloud.PrimaryIP, error) {
+	ip, err := withRetry(ctx, c, func() (*hcloud.PrimaryIP, *hcloud.Response, error) {
+		return c.hcloud.PrimaryIP.Update(ctx, ip, opts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not update primary IP: %w", err)
+	}
+	return ip, nil
+}
+
+func (c *Client) DeletePrimaryIP(ctx context.Context, ip *hcloud.PrimaryIP) error {
+	_, err := withRetry(ctx, c, withoutResult(func() (*hcloud.Response, error) {
+		return c.hcloud.PrimaryIP.Delete(ctx, ip)
+	}))
+	if err != nil {
+		return fmt.Errorf("could not delete primary IP: %w", err)
+	}
+	return nil
+}
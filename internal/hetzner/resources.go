@@ -13,7 +13,9 @@ func (c *Client) GetLocation(ctx context.Context, name string) (*hcloud.Location
 		return nil, nil
 	}
 
-	location, _, err := c.hcloud.Location.GetByName(ctx, name)
+	location, err := withRetry(ctx, c, func() (*hcloud.Location, *hcloud.Response, error) {
+		return c.hcloud.Location.GetByName(ctx, name)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get location by name: %w", err)
 	}
@@ -23,8 +25,20 @@ func (c *Client) GetLocation(ctx context.Context, name string) (*hcloud.Location
 	return location, nil
 }
 
+func (c *Client) GetLocations(ctx context.Context) ([]*hcloud.Location, error) {
+	locations, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.Location, error) {
+		return c.hcloud.Location.All(ctx)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not list locations: %w", err)
+	}
+	return locations, nil
+}
+
 func (c *Client) GetServerType(ctx context.Context, name string) (*hcloud.ServerType, error) {
-	stype, _, err := c.hcloud.ServerType.GetByName(ctx, name)
+	stype, err := withRetry(ctx, c, func() (*hcloud.ServerType, *hcloud.Response, error) {
+		return c.hcloud.ServerType.GetByName(ctx, name)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get server type by name: %w", err)
 	}
@@ -35,7 +49,9 @@ func (c *Client) GetServerType(ctx context.Context, name string) (*hcloud.Server
 }
 
 func (c *Client) GetImageByID(ctx context.Context, id int64) (*hcloud.Image, error) {
-	image, _, err := c.hcloud.Image.GetByID(ctx, id)
+	image, err := withRetry(ctx, c, func() (*hcloud.Image, *hcloud.Response, error) {
+		return c.hcloud.Image.GetByID(ctx, id)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get image by ID %v: %w", id, err)
 	}
@@ -46,7 +62,9 @@ func (c *Client) GetImageByID(ctx context.Context, id int64) (*hcloud.Image, err
 }
 
 func (c *Client) GetImageByNameAndArch(ctx context.Context, name string, arch hcloud.Architecture) (*hcloud.Image, error) {
-	image, _, err := c.hcloud.Image.GetByNameAndArchitecture(ctx, name, arch)
+	image, err := withRetry(ctx, c, func() (*hcloud.Image, *hcloud.Response, error) {
+		return c.hcloud.Image.GetByNameAndArchitecture(ctx, name, arch)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get image by name %v: %w", name, err)
 	}
@@ -70,7 +88,9 @@ func (c *Client) GetPrimaryIP(ctx context.Context, nameOrIP string) (*hcloud.Pri
 		getter = client.Get
 	}
 
-	ip, _, err := getter(ctx, nameOrIP)
+	ip, err := withRetry(ctx, c, func() (*hcloud.PrimaryIP, *hcloud.Response, error) {
+		return getter(ctx, nameOrIP)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get primary IP: %w", err)
 	}
@@ -81,7 +101,9 @@ func (c *Client) GetPrimaryIP(ctx context.Context, nameOrIP string) (*hcloud.Pri
 }
 
 func (c *Client) GetNetwork(ctx context.Context, nameOrID string) (*hcloud.Network, error) {
-	network, _, err := c.hcloud.Network.Get(ctx, nameOrID)
+	network, err := withRetry(ctx, c, func() (*hcloud.Network, *hcloud.Response, error) {
+		return c.hcloud.Network.Get(ctx, nameOrID)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get network by ID or name: %w", err)
 	}
@@ -92,7 +114,9 @@ func (c *Client) GetNetwork(ctx context.Context, nameOrID string) (*hcloud.Netwo
 }
 
 func (c *Client) GetFirewall(ctx context.Context, nameOrID string) (*hcloud.Firewall, error) {
-	firewall, _, err := c.hcloud.Firewall.Get(ctx, nameOrID)
+	firewall, err := withRetry(ctx, c, func() (*hcloud.Firewall, *hcloud.Response, error) {
+		return c.hcloud.Firewall.Get(ctx, nameOrID)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get firewall by ID or name: %w", err)
 	}
@@ -103,7 +127,9 @@ func (c *Client) GetFirewall(ctx context.Context, nameOrID string) (*hcloud.Fire
 }
 
 func (c *Client) GetVolume(ctx context.Context, nameOrID string) (*hcloud.Volume, error) {
-	volume, _, err := c.hcloud.Volume.Get(ctx, nameOrID)
+	volume, err := withRetry(ctx, c, func() (*hcloud.Volume, *hcloud.Response, error) {
+		return c.hcloud.Volume.Get(ctx, nameOrID)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get volume by ID or name: %w", err)
 	}
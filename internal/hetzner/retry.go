@@ -0,0 +1,181 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/logging"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	DefaultRetryBaseDelay = 1 * time.Second
+	DefaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy configures how a [Client] retries API calls failing due to transient errors;
+// the zero value disables retries
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+func (p RetryPolicy) enabled() bool {
+	return p.MaxRetries > 0
+}
+
+func (p RetryPolicy) backoff(retries int) time.Duration {
+	base, maxDelay := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	return hcloud.ExponentialBackoffWithOpts(hcloud.ExponentialBackoffOpts{
+		Base:       base,
+		Multiplier: 2,
+		Cap:        maxDelay,
+		Jitter:     true,
+	})(retries)
+}
+
+type failureKind int
+
+const (
+	// the request failed for good, retrying will not help
+	failurePermanent failureKind = iota
+	// the API refused the request without acting on it, so it is always safe to retry
+	failureRejected
+	// the request may or may not have taken effect
+	failureAmbiguous
+)
+
+func classifyFailure(resp *hcloud.Response, err error) failureKind {
+	if hcloud.IsError(err,
+		hcloud.ErrorCodeRateLimitExceeded,
+		hcloud.ErrorCodeConflict,
+		hcloud.ErrorCodeLocked,
+		hcloud.ErrorCodeRobotUnavailable,
+	) {
+		return failureRejected
+	}
+
+	if hcloud.IsError(err,
+		hcloud.ErrorCodeServiceError,
+		hcloud.ErrorCodeServerError,
+		hcloud.ErrorCodeUnknownError,
+		hcloud.ErrorCodeTimeout,
+	) {
+		return failureAmbiguous
+	}
+
+	if errors.Is(err, hcloud.ErrStatusCode) && resp != nil && resp.Response != nil {
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			return failureRejected
+		case resp.StatusCode >= http.StatusInternalServerError:
+			return failureAmbiguous
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) {
+		return failureAmbiguous
+	}
+
+	return failurePermanent
+}
+
+// withRetry runs an idempotent API call under the client's retry policy
+func withRetry[T any](ctx context.Context, c *Client, call func() (T, *hcloud.Response, error)) (T, error) {
+	return withRetryOrRecover(ctx, c, nil, call)
+}
+
+// withRetryOrRecover runs a non-idempotent API call under the client's retry policy. Rejected requests
+// are retried as-is; after an ambiguous failure, lookup must confirm that the call did not take effect
+// before it is repeated, and a resource found by lookup is returned instead. Without lookup, calls are
+// considered idempotent.
+//
+// Lookups of created resources search by the requested name or key, which is unique. A resource found this
+// way is only the one created by the failed call if it carries the instance ID label of the request, see
+// verifyCreatedByCall; anything else merely shares the name and must never be returned, as the caller would
+// e.g. delete it on rollback.
+func withRetryOrRecover[T any](ctx context.Context, c *Client, lookup func() (T, bool, error), call func() (T, *hcloud.Response, error)) (T, error) {
+	policy := c.retryPolicy
+
+	for retries := 0; ; retries++ {
+		result, resp, err := call()
+		if err == nil || !policy.enabled() || retries >= policy.MaxRetries || ctx.Err() != nil {
+			return result, err
+		}
+
+		switch classifyFailure(resp, err) {
+		case failurePermanent:
+			return result, err
+		case failureAmbiguous:
+			if lookup != nil {
+				existing, found, lookupErr := lookup()
+				if lookupErr != nil {
+					// retrying without knowing whether the first call succeeded risks duplicates
+					logging.DebugStep("Not retrying, could not verify outcome of failed call: %v", lookupErr)
+					return result, err
+				}
+				if found {
+					logging.DebugStep("Call reported failure but took effect, continuing: %v", err)
+					return existing, nil
+				}
+			}
+		}
+
+		delay := policy.backoff(retries)
+		logging.DebugStep("Retrying in %v (%d/%d): %v", delay, retries+1, policy.MaxRetries, err)
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// errForeignResource reports that a resource found after an ambiguous failure was not created by the call
+var errForeignResource = errors.New("resource of the requested name was not created by this call")
+
+// verifyCreatedByCall confirms that a resource found after an ambiguous create failure carries the instance ID
+// label of the create request
+func verifyCreatedByCall(requested, found map[string]string) error {
+	label := config.LabelName(config.LabelInstanceID)
+	if id := requested[label]; id == "" || found[label] != id {
+		return errForeignResource
+	}
+	return nil
+}
+
+// withoutResult adapts API calls that only return a response
+func withoutResult(call func() (*hcloud.Response, error)) func() (struct{}, *hcloud.Response, error) {
+	return func() (struct{}, *hcloud.Response, error) {
+		resp, err := call()
+		return struct{}{}, resp, err
+	}
+}
+
+// withoutResponse adapts API calls that do not return a response, such as listing all pages
+func withoutResponse[T any](call func() (T, error)) func() (T, *hcloud.Response, error) {
+	return func() (T, *hcloud.Response, error) {
+		result, err := call()
+		return result, nil, err
+	}
+}
@@ -0,0 +1,163 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/config"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	statusResponse := func(code int) *hcloud.Response {
+		return &hcloud.Response{Response: &http.Response{StatusCode: code}}
+	}
+
+	tests := []struct {
+		name     string
+		resp     *hcloud.Response
+		err      error
+		expected failureKind
+	}{
+		{"rate limit", nil, hcloud.Error{Code: hcloud.ErrorCodeRateLimitExceeded}, failureRejected},
+		{"locked", nil, hcloud.Error{Code: hcloud.ErrorCodeLocked}, failureRejected},
+		{"wrapped conflict", nil, fmt.Errorf("wrapped: %w", hcloud.Error{Code: hcloud.ErrorCodeConflict}), failureRejected},
+		{"server error", nil, hcloud.Error{Code: hcloud.ErrorCodeServerError}, failureAmbiguous},
+		{"bare 503", statusResponse(http.StatusServiceUnavailable), fmt.Errorf("hcloud: %w 503", hcloud.ErrStatusCode), failureAmbiguous},
+		{"bare 429", statusResponse(http.StatusTooManyRequests), fmt.Errorf("hcloud: %w 429", hcloud.ErrStatusCode), failureRejected},
+		{"connection reset", nil, fmt.Errorf("read: %w", syscall.ECONNRESET), failureAmbiguous},
+		{"not found", nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound}, failurePermanent},
+		{"invalid input", nil, hcloud.Error{Code: hcloud.ErrorCodeInvalidInput}, failurePermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := classifyFailure(tt.resp, tt.err); result != tt.expected {
+				t.Errorf("classifyFailure(%v) = %v, want %v", tt.err, result, tt.expected)
+			}
+		})
+	}
+}
+
+func testClient(retries int) *Client {
+	return &Client{retryPolicy: RetryPolicy{MaxRetries: retries, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}}
+}
+
+func TestWithRetry(t *testing.T) {
+	locked := hcloud.Error{Code: hcloud.ErrorCodeLocked}
+
+	// disabled policy makes a single attempt
+	calls := 0
+	_, err := withRetry(context.Background(), testClient(0), func() (int, *hcloud.Response, error) {
+		calls++
+		return 0, nil, locked
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("expected a single failed attempt, got %d calls and %v", calls, err)
+	}
+
+	// transient failures are retried until success
+	calls = 0
+	result, err := withRetry(context.Background(), testClient(3), func() (int, *hcloud.Response, error) {
+		calls++
+		if calls < 3 {
+			return 0, nil, locked
+		}
+		return 42, nil, nil
+	})
+	if err != nil || result != 42 || calls != 3 {
+		t.Errorf("expected success on third attempt, got %d calls, result %d and %v", calls, result, err)
+	}
+
+	// retries are bounded
+	calls = 0
+	_, err = withRetry(context.Background(), testClient(2), func() (int, *hcloud.Response, error) {
+		calls++
+		return 0, nil, locked
+	})
+	if !errors.Is(err, locked) || calls != 3 {
+		t.Errorf("expected 3 attempts, got %d calls and %v", calls, err)
+	}
+
+	// permanent failures are not retried
+	calls = 0
+	_, _ = withRetry(context.Background(), testClient(3), func() (int, *hcloud.Response, error) {
+		calls++
+		return 0, nil, hcloud.Error{Code: hcloud.ErrorCodeInvalidInput}
+	})
+	if calls != 1 {
+		t.Errorf("expected a single attempt, got %d calls", calls)
+	}
+}
+
+func TestWithRetryOrRecover(t *testing.T) {
+	ambiguous := hcloud.Error{Code: hcloud.ErrorCodeServerError}
+
+	// resource created despite the failure is returned instead of creating another one
+	calls := 0
+	result, err := withRetryOrRecover(context.Background(), testClient(3),
+		func() (int, bool, error) { return 7, true, nil },
+		func() (int, *hcloud.Response, error) {
+			calls++
+			return 0, nil, ambiguous
+		})
+	if err != nil || result != 7 || calls != 1 {
+		t.Errorf("expected recovered result after one call, got %d calls, result %d and %v", calls, result, err)
+	}
+
+	// call is repeated once lookup confirmed it had no effect
+	calls = 0
+	result, err = withRetryOrRecover(context.Background(), testClient(3),
+		func() (int, bool, error) { return 0, false, nil },
+		func() (int, *hcloud.Response, error) {
+			calls++
+			if calls == 1 {
+				return 0, nil, ambiguous
+			}
+			return 42, nil, nil
+		})
+	if err != nil || result != 42 || calls != 2 {
+		t.Errorf("expected success on second attempt, got %d calls, result %d and %v", calls, result, err)
+	}
+
+	// call is not repeated when the outcome cannot be verified
+	calls = 0
+	_, err = withRetryOrRecover(context.Background(), testClient(3),
+		func() (int, bool, error) { return 0, false, errors.New("lookup failed") },
+		func() (int, *hcloud.Response, error) {
+			calls++
+			return 0, nil, ambiguous
+		})
+	if !errors.Is(err, ambiguous) || calls != 1 {
+		t.Errorf("expected a single failed attempt, got %d calls and %v", calls, err)
+	}
+}
+
+func TestVerifyCreatedByCall(t *testing.T) {
+	label := config.LabelName(config.LabelInstanceID)
+
+	tests := []struct {
+		name      string
+		requested map[string]string
+		found     map[string]string
+		owned     bool
+	}{
+		{"same instance", map[string]string{label: "a"}, map[string]string{label: "a", "other": "x"}, true},
+		{"other instance", map[string]string{label: "a"}, map[string]string{label: "b"}, false},
+		{"unlabelled resource", map[string]string{label: "a"}, nil, false},
+		{"unlabelled request", nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyCreatedByCall(tt.requested, tt.found); (err == nil) != tt.owned {
+				t.Errorf("verifyCreatedByCall(%v, %v) = %v, owned %v", tt.requested, tt.found, err, tt.owned)
+			}
+		})
+	}
+}
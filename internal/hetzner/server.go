@@ -13,23 +13,97 @@ func (c *Client) GetServerByID(ctx context.Context, id int64) (*hcloud.Server, e
 		return nil, errors.New("server ID was 0")
 	}
 
-	srv, _, err := c.hcloud.Server.GetByID(ctx, id)
+	srv, err := withRetry(ctx, c, func() (*hcloud.Server, *hcloud.Response, error) {
+		return c.hcloud.Server.GetByID(ctx, id)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get server by ID: %w", err)
 	}
 	return srv, nil
 }
 
+func (c *Client) GetServer(ctx context.Context, nameOrID string) (*hcloud.Server, error) {
+	srv, err := withRetry(ctx, c, func() (*hcloud.Server, *hcloud.Response, error) {
+		return c.hcloud.Server.Get(ctx, nameOrID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get server by ID or name: %w", err)
+	}
+	if srv == nil {
+		return nil, fmt.Errorf("server '%s' not found", nameOrID)
+	}
+	return srv, nil
+}
+
+func (c *Client) GetServerByName(ctx context.Context, name string) (*hcloud.Server, error) {
+	srv, err := withRetry(ctx, c, func() (*hcloud.Server, *hcloud.Response, error) {
+		return c.hcloud.Server.GetByName(ctx, name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get server by name: %w", err)
+	}
+	return srv, nil
+}
+
+func (c *Client) GetServersByLabel(ctx context.Context, labelSelector string) ([]*hcloud.Server, error) {
+	servers, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.Server, error) {
+		return c.hcloud.Server.AllWithOpts(ctx, hcloud.ServerListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+		})
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not list servers: %w", err)
+	}
+	return servers, nil
+}
+
+// CreateServer creates a server; a server recovered after an ambiguous failure is returned without an action to
+// wait for
 func (c *Client) CreateServer(ctx context.Context, opts hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, error) {
-	result, _, err := c.hcloud.Server.Create(ctx, opts)
+	lookup := func() (hcloud.ServerCreateResult, bool, error) {
+		srv, _, err := c.hcloud.Server.GetByName(ctx, opts.Name)
+		if err != nil || srv == nil {
+			return hcloud.ServerCreateResult{}, false, err
+		}
+		return hcloud.ServerCreateResult{Server: srv}, true, verifyCreatedByCall(opts.Labels, srv.Labels)
+	}
+
+	result, err := withRetryOrRecover(ctx, c, lookup, func() (hcloud.ServerCreateResult, *hcloud.Response, error) {
+		return c.hcloud.Server.Create(ctx, opts)
+	})
 	if err != nil {
 		return hcloud.ServerCreateResult{}, fmt.Errorf("could not create server: %w", err)
 	}
 	return result, nil
 }
 
+// IsUnavailableError reports whether err signals that Hetzner currently lacks the capacity to
+// fulfil a server creation request, so that retrying elsewhere may succeed.
+func IsUnavailableError(err error) bool {
+	return hcloud.IsError(err, hcloud.ErrorCodeResourceUnavailable, hcloud.ErrorCodePlacementError)
+}
+
+// IsInvalidServerTypeError reports whether err signals that the requested server type cannot be used,
+// e.g. because it is deprecated or does not fit the request
+func IsInvalidServerTypeError(err error) bool {
+	return hcloud.IsError(err, hcloud.ErrorCodeInvalidServerType)
+}
+
+// IsNotFoundError reports whether err signals that a resource no longer exists
+func IsNotFoundError(err error) bool {
+	return hcloud.IsError(err, hcloud.ErrorCodeNotFound)
+}
+
+// IsUniquenessError reports whether err signals that a resource of the same name already exists, e.g. because it was
+// created concurrently
+func IsUniquenessError(err error) bool {
+	return hcloud.IsError(err, hcloud.ErrorCodeUniquenessError)
+}
+
 func (c *Client) DeleteServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
-	result, _, err := c.hcloud.Server.DeleteWithResult(ctx, server)
+	result, err := withRetry(ctx, c, func() (*hcloud.ServerDeleteResult, *hcloud.Response, error) {
+		return c.hcloud.Server.DeleteWithResult(ctx, server)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not delete server: %w", err)
 	}
@@ -37,7 +111,9 @@ func (c *Client) DeleteServer(ctx context.Context, server *hcloud.Server) (*hclo
 }
 
 func (c *Client) RebootServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
-	action, _, err := c.hcloud.Server.Reboot(ctx, server)
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Server.Reboot(ctx, server)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not reboot server: %w", err)
 	}
@@ -45,15 +121,60 @@ func (c *Client) RebootServer(ctx context.Context, server *hcloud.Server) (*hclo
 }
 
 func (c *Client) PowerOnServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
-	action, _, err := c.hcloud.Server.Poweron(ctx, server)
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Server.Poweron(ctx, server)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not power on server: %w", err)
 	}
 	return action, nil
 }
 
+// RebuildServer overwrites the server's disk with an image; it is not repeated after an ambiguous failure, as
+// another rebuild would replace the root password returned by the first one
+func (c *Client) RebuildServer(ctx context.Context, server *hcloud.Server, opts hcloud.ServerRebuildOpts) (hcloud.ServerRebuildResult, error) {
+	lookup := func() (hcloud.ServerRebuildResult, bool, error) {
+		return hcloud.ServerRebuildResult{}, false, errors.New("outcome of rebuild cannot be verified")
+	}
+
+	result, err := withRetryOrRecover(ctx, c, lookup, func() (hcloud.ServerRebuildResult, *hcloud.Response, error) {
+		return c.hcloud.Server.RebuildWithResult(ctx, server, opts)
+	})
+	if err != nil {
+		return hcloud.ServerRebuildResult{}, fmt.Errorf("could not rebuild server: %w", err)
+	}
+	return result, nil
+}
+
+// AttachServerToNetwork attaches a running server to a network; if the server turns out to be attached after an
+// ambiguous failure, no action is returned
+func (c *Client) AttachServerToNetwork(ctx context.Context, server *hcloud.Server, opts hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, error) {
+	lookup := func() (*hcloud.Action, bool, error) {
+		srv, _, err := c.hcloud.Server.GetByID(ctx, server.ID)
+		if err != nil || srv == nil {
+			return nil, false, err
+		}
+		for _, privateNet := range srv.PrivateNet {
+			if privateNet.Network != nil && privateNet.Network.ID == opts.Network.ID {
+				return nil, true, nil
+			}
+		}
+		return nil, false, nil
+	}
+
+	action, err := withRetryOrRecover(ctx, c, lookup, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Server.AttachToNetwork(ctx, server, opts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not attach server to network: %w", err)
+	}
+	return action, nil
+}
+
 func (c *Client) ShutdownServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
-	action, _, err := c.hcloud.Server.Shutdown(ctx, server)
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Server.Shutdown(ctx, server)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not shutdown server: %w", err)
 	}
@@ -61,9 +182,43 @@ func (c *Client) ShutdownServer(ctx context.Context, server *hcloud.Server) (*hc
 }
 
 func (c *Client) PowerOffServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
-	action, _, err := c.hcloud.Server.Poweroff(ctx, server)
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Server.Poweroff(ctx, server)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not power off server: %w", err)
 	}
 	return action, nil
 }
+
+// ChangeServerDNSPtr sets the reverse DNS entry of one of the server's public addresses; a nil ptr resets it to the
+// default entry
+func (c *Client) ChangeServerDNSPtr(ctx context.Context, server *hcloud.Server, ip string, ptr *string) (*hcloud.Action, error) {
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Server.ChangeDNSPtr(ctx, server, ip, ptr)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not change reverse DNS of %v: %w", ip, err)
+	}
+	return action, nil
+}
+
+func (c *Client) DetachServerFromNetwork(ctx context.Context, server *hcloud.Server, network *hcloud.Network) (*hcloud.Action, error) {
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Server.DetachFromNetwork(ctx, server, hcloud.ServerDetachFromNetworkOpts{Network: network})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not detach server from network: %w", err)
+	}
+	return action, nil
+}
+
+func (c *Client) UpdateServer(ctx context.Context, server *hcloud.Server, opts hcloud.ServerUpdateOpts) (*hcloud.Server, error) {
+	srv, err := withRetry(ctx, c, func() (*hcloud.Server, *hcloud.Response, error) {
+		return c.hcloud.Server.Update(ctx, server, opts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not update server: %w", err)
+	}
+	return srv, nil
+}
@@ -9,7 +9,9 @@ import (
 )
 
 func (c *Client) GetSSHKeyByID(ctx context.Context, id int64) (*hcloud.SSHKey, error) {
-	key, _, err := c.hcloud.SSHKey.GetByID(ctx, id)
+	key, err := withRetry(ctx, c, func() (*hcloud.SSHKey, *hcloud.Response, error) {
+		return c.hcloud.SSHKey.GetByID(ctx, id)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get SSH key by ID: %w", err)
 	}
@@ -17,7 +19,9 @@ func (c *Client) GetSSHKeyByID(ctx context.Context, id int64) (*hcloud.SSHKey, e
 }
 
 func (c *Client) GetSSHKeyByFingerprint(ctx context.Context, fingerprint string) (*hcloud.SSHKey, error) {
-	key, _, err := c.hcloud.SSHKey.GetByFingerprint(ctx, fingerprint)
+	key, err := withRetry(ctx, c, func() (*hcloud.SSHKey, *hcloud.Response, error) {
+		return c.hcloud.SSHKey.GetByFingerprint(ctx, fingerprint)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get SSH key by fingerprint: %w", err)
 	}
@@ -34,8 +38,31 @@ func (c *Client) GetSSHKeyByPublicKey(ctx context.Context, publicKeyBytes []byte
 	return c.GetSSHKeyByFingerprint(ctx, fingerprint)
 }
 
+func (c *Client) GetSSHKeysByLabel(ctx context.Context, labelSelector string) ([]*hcloud.SSHKey, error) {
+	keys, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.SSHKey, error) {
+		return c.hcloud.SSHKey.AllWithOpts(ctx, hcloud.SSHKeyListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+		})
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not list SSH keys: %w", err)
+	}
+	return keys, nil
+}
+
+// CreateSSHKey uploads a public key; a key recovered after an ambiguous failure is looked up by its public key
 func (c *Client) CreateSSHKey(ctx context.Context, opts hcloud.SSHKeyCreateOpts) (*hcloud.SSHKey, error) {
-	key, _, err := c.hcloud.SSHKey.Create(ctx, opts)
+	lookup := func() (*hcloud.SSHKey, bool, error) {
+		key, err := c.GetSSHKeyByPublicKey(ctx, []byte(opts.PublicKey))
+		if err != nil || key == nil {
+			return nil, false, err
+		}
+		return key, true, verifyCreatedByCall(opts.Labels, key.Labels)
+	}
+
+	key, err := withRetryOrRecover(ctx, c, lookup, func() (*hcloud.SSHKey, *hcloud.Response, error) {
+		return c.hcloud.SSHKey.Create(ctx, opts)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not create SSH key: %w", err)
 	}
@@ -46,7 +73,9 @@ func (c *Client) CreateSSHKey(ctx context.Context, opts hcloud.SSHKeyCreateOpts)
 }
 
 func (c *Client) DeleteSSHKey(ctx context.Context, key *hcloud.SSHKey) error {
-	_, err := c.hcloud.SSHKey.Delete(ctx, key)
+	_, err := withRetry(ctx, c, withoutResult(func() (*hcloud.Response, error) {
+		return c.hcloud.SSHKey.Delete(ctx, key)
+	}))
 	if err != nil {
 		return fmt.Errorf("could not delete SSH key: %w", err)
 	}
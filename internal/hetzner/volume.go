@@ -0,0 +1,90 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (c *Client) GetVolumeByID(ctx context.Context, id int64) (*hcloud.Volume, error) {
+	volume, err := withRetry(ctx, c, func() (*hcloud.Volume, *hcloud.Response, error) {
+		return c.hcloud.Volume.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get volume by ID: %w", err)
+	}
+	return volume, nil
+}
+
+func (c *Client) GetVolumeByName(ctx context.Context, name string) (*hcloud.Volume, error) {
+	volume, err := withRetry(ctx, c, func() (*hcloud.Volume, *hcloud.Response, error) {
+		return c.hcloud.Volume.GetByName(ctx, name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get volume by name: %w", err)
+	}
+	return volume, nil
+}
+
+func (c *Client) GetVolumesByLabel(ctx context.Context, labelSelector string) ([]*hcloud.Volume, error) {
+	volumes, err := withRetry(ctx, c, withoutResponse(func() ([]*hcloud.Volume, error) {
+		return c.hcloud.Volume.AllWithOpts(ctx, hcloud.VolumeListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+		})
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not list volumes: %w", err)
+	}
+	return volumes, nil
+}
+
+// CreateVolume creates a volume; a volume recovered after an ambiguous failure is returned without an action to
+// wait for
+func (c *Client) CreateVolume(ctx context.Context, opts hcloud.VolumeCreateOpts) (hcloud.VolumeCreateResult, error) {
+	lookup := func() (hcloud.VolumeCreateResult, bool, error) {
+		volume, _, err := c.hcloud.Volume.GetByName(ctx, opts.Name)
+		if err != nil || volume == nil {
+			return hcloud.VolumeCreateResult{}, false, err
+		}
+		return hcloud.VolumeCreateResult{Volume: volume}, true, verifyCreatedByCall(opts.Labels, volume.Labels)
+	}
+
+	result, err := withRetryOrRecover(ctx, c, lookup, func() (hcloud.VolumeCreateResult, *hcloud.Response, error) {
+		return c.hcloud.Volume.Create(ctx, opts)
+	})
+	if err != nil {
+		return hcloud.VolumeCreateResult{}, fmt.Errorf("could not create volume: %w", err)
+	}
+	return result, nil
+}
+
+func (c *Client) UpdateVolume(ctx context.Context, volume *hcloud.Volume, opts hcloud.VolumeUpdateOpts) (*hcloud.Volume, error) {
+	volume, err := withRetry(ctx, c, func() (*hcloud.Volume, *hcloud.Response, error) {
+		return c.hcloud.Volume.Update(ctx, volume, opts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not update volume: %w", err)
+	}
+	return volume, nil
+}
+
+func (c *Client) DetachVolume(ctx context.Context, volume *hcloud.Volume) (*hcloud.Action, error) {
+	action, err := withRetry(ctx, c, func() (*hcloud.Action, *hcloud.Response, error) {
+		return c.hcloud.Volume.Detach(ctx, volume)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not detach volume: %w", err)
+	}
+	return action, nil
+}
+
+func (c *Client) DeleteVolume(ctx context.Context, volume *hcloud.Volume) error {
+	_, err := withRetry(ctx, c, withoutResult(func() (*hcloud.Response, error) {
+		return c.hcloud.Volume.Delete(ctx, volume)
+	}))
+	if err != nil {
+		return fmt.Errorf("could not delete volume: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/driver"
+)
+
+// Command is the name of the subcommand, passed as first argument to the driver binary
+const Command = "reconcile"
+
+// driverName is the name docker-machine stores for machines using this driver
+const driverName = "hetzner"
+
+type options struct {
+	storagePath string
+	machines    []string
+	apply       bool
+}
+
+// Run executes the reconcile subcommand with the arguments following it and returns the process exit code
+func Run(args []string, version string) int {
+	opts, err := parseArgs(args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	failed := 0
+	for _, name := range opts.machines {
+		if err := reconcileMachine(opts, name, version, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", name, err)
+			failed++
+		}
+	}
+	if failed != 0 {
+		return 1
+	}
+	return 0
+}
+
+func parseArgs(args []string, output io.Writer) (options, error) {
+	var opts options
+
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: docker-machine-driver-hetzner %s [options] <machine>...\n\n", Command)
+		fmt.Fprintf(output, "Lists differences between the firewalls, networks and labels of each machine's server and its stored\n")
+		fmt.Fprintf(output, "configuration, and brings the server in line with --apply.\n\n")
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&opts.storagePath, "storage-path", os.Getenv("MACHINE_STORAGE_PATH"), "docker-machine store directory holding the machines (env: MACHINE_STORAGE_PATH)")
+	fs.BoolVar(&opts.apply, "apply", false, "Change the servers instead of only reporting differences")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	opts.machines = fs.Args()
+	if len(opts.machines) == 0 {
+		return opts, errors.New("missing machine name")
+	}
+	if opts.storagePath == "" {
+		return opts, errors.New("missing machine store, use --storage-path or MACHINE_STORAGE_PATH")
+	}
+	return opts, nil
+}
+
+// machineConfigPath returns the location of a machine's config.json within the store
+func machineConfigPath(storagePath, name string) string {
+	return filepath.Join(storagePath, "machines", name, "config.json")
+}
+
+// loadMachine reads the raw machine config and the driver configuration stored within it
+func loadMachine(path, version string) (map[string]json.RawMessage, *driver.Driver, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read machine config: %w", err)
+	}
+
+	var host map[string]json.RawMessage
+	if err = json.Unmarshal(buf, &host); err != nil {
+		return nil, nil, fmt.Errorf("could not parse machine config: %w", err)
+	}
+
+	var name string
+	if err = json.Unmarshal(host["DriverName"], &name); err != nil || name != driverName {
+		return nil, nil, fmt.Errorf("machine does not use the %v driver", driverName)
+	}
+
+	d := driver.NewDriver(version)
+	if err = json.Unmarshal(host["Driver"], d); err != nil {
+		return nil, nil, fmt.Errorf("could not parse driver config: %w", err)
+	}
+	return host, d, nil
+}
+
+// saveMachine writes the driver configuration back, keeping the rest of the machine config as is
+func saveMachine(path string, host map[string]json.RawMessage, d *driver.Driver) error {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	host["Driver"] = raw
+
+	buf, err := json.MarshalIndent(host, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, buf, 0600); err != nil {
+		return fmt.Errorf("could not write machine config: %w", err)
+	}
+	return nil
+}
+
+func reconcileMachine(opts options, name, version string, out io.Writer) error {
+	path := machineConfigPath(opts.storagePath, name)
+	host, d, err := loadMachine(path, version)
+	if err != nil {
+		return err
+	}
+
+	changes, err := d.Reconcile(opts.apply)
+	for _, change := range changes {
+		fmt.Fprintf(out, "%s: %s\n", name, change)
+	}
+	if opts.apply {
+		// IDs of re-created resources must be kept even if a later change failed
+		if saveErr := saveMachine(path, host, d); saveErr != nil {
+			return errors.Join(err, saveErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(changes) == 0:
+		fmt.Fprintf(out, "%s: in line with its configuration\n", name)
+	case !opts.apply:
+		fmt.Fprintf(out, "%s: %d difference(s) found, run with --apply to reconcile\n", name, len(changes))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	t.Setenv("MACHINE_STORAGE_PATH", "")
+
+	if _, err := parseArgs([]string{"--storage-path", "/tmp/store"}, io.Discard); err == nil {
+		t.Errorf("expected error without machine")
+	}
+	if _, err := parseArgs([]string{"node-1"}, io.Discard); err == nil {
+		t.Errorf("expected error without store")
+	}
+
+	t.Setenv("MACHINE_STORAGE_PATH", "/tmp/store")
+	opts, err := parseArgs([]string{"--apply", "node-1", "node-2"}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.storagePath != "/tmp/store" || !opts.apply || !slices.Equal(opts.machines, []string{"node-1", "node-2"}) {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+}
+
+func TestLoadAndSaveMachine(t *testing.T) {
+	store := t.TempDir()
+	for name, cfg := range map[string]string{
+		"node-1": `{"ConfigVersion": 3, "DriverName": "hetzner", "Driver": {"MachineName": "node-1", "ServerID": 42, "Firewalls": ["web"]}}`,
+		"other":  `{"ConfigVersion": 3, "DriverName": "digitalocean", "Driver": {}}`,
+	} {
+		dir := filepath.Join(store, "machines", name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := loadMachine(machineConfigPath(store, "other"), "test"); err == nil {
+		t.Error("expected error for machine of another driver")
+	}
+	if _, _, err := loadMachine(machineConfigPath(store, "missing"), "test"); err == nil {
+		t.Error("expected error for missing machine")
+	}
+
+	path := machineConfigPath(store, "node-1")
+	host, d, err := loadMachine(path, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.ServerID != 42 || d.GetMachineName() != "node-1" || !slices.Equal(d.Firewalls, []string{"web"}) {
+		t.Fatalf("unexpected driver config: %+v", d)
+	}
+
+	d.DockerFirewallID = 7
+	if err = saveMachine(path, host, d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved struct {
+		ConfigVersion int
+		Driver        struct {
+			ServerID         int64
+			DockerFirewallID int64
+		}
+	}
+	if err = json.Unmarshal(buf, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.ConfigVersion != 3 || saved.Driver.ServerID != 42 || saved.Driver.DockerFirewallID != 7 {
+		t.Errorf("unexpected saved config: %s", buf)
+	}
+}
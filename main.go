@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/driver"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/gc"
+	"github.com/CosmoAbdon/docker-machine-driver-hetzner/internal/reconcile"
 	"github.com/docker/machine/libmachine/drivers/plugin"
 )
 
@@ -16,6 +18,13 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == gc.Command {
+		os.Exit(gc.Run(os.Args[2:], version))
+	}
+	if len(os.Args) > 1 && os.Args[1] == reconcile.Command {
+		os.Exit(reconcile.Run(os.Args[2:], version))
+	}
+
 	var (
 		versionFlag = flag.Bool("version", false, "Print version information")
 		vFlag       = flag.Bool("v", false, "Print version (short)")